@@ -0,0 +1,305 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/daemon/libnetwork"
+	"github.com/moby/swarmkit/v2/api"
+	"golang.org/x/sync/errgroup"
+)
+
+type fakeAttachmentStore map[string]net.IP
+
+func (f fakeAttachmentStore) GetIPForNetwork(networkID string) (net.IP, bool) {
+	ip, ok := f[networkID]
+	return ip, ok
+}
+
+func TestNodeAttachmentsReady(t *testing.T) {
+	c := &containerAdapter{}
+
+	cases := []struct {
+		name       string
+		store      fakeAttachmentStore
+		networkIDs []string
+		want       bool
+	}{
+		{
+			name:       "no networks required",
+			store:      fakeAttachmentStore{},
+			networkIDs: nil,
+			want:       true,
+		},
+		{
+			name:       "all networks attached",
+			store:      fakeAttachmentStore{"net1": net.ParseIP("10.0.0.1"), "net2": net.ParseIP("10.0.0.2")},
+			networkIDs: []string{"net1", "net2"},
+			want:       true,
+		},
+		{
+			name:       "one network still missing",
+			store:      fakeAttachmentStore{"net1": net.ParseIP("10.0.0.1")},
+			networkIDs: []string{"net1", "net2"},
+			want:       false,
+		},
+		{
+			name:       "no networks attached yet",
+			store:      fakeAttachmentStore{},
+			networkIDs: []string{"net1"},
+			want:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ready, err := c.nodeAttachmentsReady(tc.store, tc.networkIDs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ready != tc.want {
+				t.Errorf("nodeAttachmentsReady() = %v, want %v", ready, tc.want)
+			}
+		})
+	}
+}
+
+// TestPollUntilReadyWakesManyWaitersWithinOneTick starts many concurrent
+// waiters (simulating many tasks on a node all waiting on the same
+// attachment) against a check that only becomes ready partway through the
+// poll interval, and asserts every waiter notices within a small multiple of
+// one tick - the worst case this poll-only implementation can offer, since
+// nothing here signals readiness early.
+func TestPollUntilReadyWakesManyWaitersWithinOneTick(t *testing.T) {
+	const (
+		numWaiters = 50
+		interval   = 10 * time.Millisecond
+	)
+
+	var ready atomicBool
+	go func() {
+		time.Sleep(interval / 2)
+		ready.set(true)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	latencies := make([]time.Duration, numWaiters)
+	start := time.Now()
+	for i := 0; i < numWaiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pollUntilReady(ctx, interval, func() (bool, error) {
+				return ready.get(), nil
+			})
+			if err != nil {
+				t.Errorf("pollUntilReady: %v", err)
+			}
+			latencies[i] = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+
+	const worstCaseBound = 5 * interval
+	for i, l := range latencies {
+		if l > worstCaseBound {
+			t.Errorf("waiter %d took %v to notice readiness, want <= %v", i, l, worstCaseBound)
+		}
+	}
+}
+
+// atomicBool is a tiny test helper; the production path only needs
+// GetIPForNetwork-style polling, not a real atomic type.
+type atomicBool struct {
+	mu sync.Mutex
+	v  bool
+}
+
+func (a *atomicBool) set(v bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.v = v
+}
+
+func (a *atomicBool) get() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.v
+}
+
+func TestTaskOwnerLabels(t *testing.T) {
+	task := &api.Task{
+		ID:        "task1",
+		ServiceID: "service1",
+		NodeID:    "node1",
+	}
+
+	got := taskOwnerLabels(task)
+	want := map[string]string{
+		"com.docker.swarm.task.id":    "task1",
+		"com.docker.swarm.service.id": "service1",
+		"com.docker.swarm.node.id":    "node1",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d labels, want %d: %+v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("label %q = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestPullMaxAttempts(t *testing.T) {
+	cases := []struct {
+		name   string
+		labels map[string]string
+		want   int
+	}{
+		{"no label defaults", nil, defaultMaxPullAttempts},
+		{"empty label defaults", map[string]string{pullMaxAttemptsLabel: ""}, defaultMaxPullAttempts},
+		{"non-numeric label defaults", map[string]string{pullMaxAttemptsLabel: "many"}, defaultMaxPullAttempts},
+		{"zero label defaults", map[string]string{pullMaxAttemptsLabel: "0"}, defaultMaxPullAttempts},
+		{"negative label defaults", map[string]string{pullMaxAttemptsLabel: "-1"}, defaultMaxPullAttempts},
+		{"positive label overrides", map[string]string{pullMaxAttemptsLabel: "7"}, 7},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &api.ContainerSpec{Labels: tc.labels}
+			if got := pullMaxAttempts(spec); got != tc.want {
+				t.Errorf("pullMaxAttempts() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestCreateNetworkIdempotentToleratesConcurrentNameConflict simulates many
+// tasks on the same node racing, via createNetworks' errgroup.SetLimit
+// fan-out, to create the same network name: only the first real call to the
+// backend should succeed, every other concurrent caller should observe
+// libnetwork.NetworkNameError or daemon.PredefinedNetworkError and must not
+// surface it as a task failure.
+func TestCreateNetworkIdempotentToleratesConcurrentNameConflict(t *testing.T) {
+	const (
+		numCallers = 50
+		name       = "overlay1"
+	)
+
+	var (
+		mu      sync.Mutex
+		created bool
+		calls   int
+	)
+	create := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if created {
+			return libnetwork.NetworkNameError(name)
+		}
+		created = true
+		return nil
+	}
+
+	eg, _ := errgroup.WithContext(context.Background())
+	eg.SetLimit(runtime.GOMAXPROCS(0))
+	for i := 0; i < numCallers; i++ {
+		eg.Go(func() error {
+			return createNetworkIdempotent(create)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("expected concurrent same-name creates to be tolerated, got: %v", err)
+	}
+	if calls != numCallers {
+		t.Errorf("create was called %d times, want %d", calls, numCallers)
+	}
+}
+
+// TestCreateNetworkIdempotentSurfacesOtherErrors checks that
+// createNetworkIdempotent only swallows the two known already-exists
+// errors and still fails the task for any other backend error.
+func TestCreateNetworkIdempotentSurfacesOtherErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		wantNil bool
+	}{
+		{"network name conflict", libnetwork.NetworkNameError("overlay1"), true},
+		{"predefined network conflict", daemon.PredefinedNetworkError("overlay1"), true},
+		{"other error", errors.New("boom"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := createNetworkIdempotent(func() error { return tc.err })
+			if tc.wantNil && err != nil {
+				t.Errorf("createNetworkIdempotent() = %v, want nil", err)
+			}
+			if !tc.wantNil && err == nil {
+				t.Error("createNetworkIdempotent() = nil, want an error")
+			}
+		})
+	}
+}
+
+// TestCreateVolumesFanOutCallsCreateOnceEachConcurrently exercises the same
+// errgroup.SetLimit-bounded fan-out shape createVolumes uses and asserts
+// that, even with many mounts running concurrently, each volume name's
+// create call happens exactly once with no duplicate or overlapping calls
+// for the same name slipping through the bounded concurrency.
+func TestCreateVolumesFanOutCallsCreateOnceEachConcurrently(t *testing.T) {
+	const numVolumes = 50
+
+	var mu sync.Mutex
+	inFlight := map[string]bool{}
+	calls := map[string]int{}
+
+	create := func(name string) error {
+		mu.Lock()
+		if inFlight[name] {
+			mu.Unlock()
+			return fmt.Errorf("duplicate concurrent create for volume %q", name)
+		}
+		inFlight[name] = true
+		calls[name]++
+		mu.Unlock()
+
+		mu.Lock()
+		inFlight[name] = false
+		mu.Unlock()
+		return nil
+	}
+
+	eg, _ := errgroup.WithContext(context.Background())
+	eg.SetLimit(runtime.GOMAXPROCS(0))
+	for i := 0; i < numVolumes; i++ {
+		name := fmt.Sprintf("vol%d", i)
+		eg.Go(func() error {
+			return create(name)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("unexpected error from bounded fan-out: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, n := range calls {
+		if n != 1 {
+			t.Errorf("volume %q was created %d times, want 1", name, n)
+		}
+	}
+}
@@ -6,11 +6,23 @@ package networkdb
 //go:generate protoc -I=. -I=../../../vendor/ --gogofaster_out=import_path=github.com/docker/docker/daemon/libnetwork/networkdb:. networkdb.proto
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
 	"math/rand/v2"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,6 +35,7 @@ import (
 	iradix "github.com/hashicorp/go-immutable-radix/v2"
 	"github.com/hashicorp/memberlist"
 	"github.com/hashicorp/serf/serf"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
@@ -95,10 +108,35 @@ type NetworkDB struct {
 	ctx       context.Context
 	cancelCtx context.CancelFunc
 
-	// A central broadcaster for all local watchers watching table
-	// events.
+	// A central broadcaster for local watchers that did not scope their
+	// subscription to a single (network, table) pair. Exact-scoped
+	// watchers are fanned out through watchBroadcasters instead, so a
+	// busy table doesn't wake up watchers of unrelated tables.
 	broadcaster *events.Broadcaster
 
+	// Per (network, table) broadcasters, created lazily as exact-scoped
+	// Watch subscribers appear.
+	watchBroadcastersMu sync.Mutex
+	watchBroadcasters   map[watchShardKey]*events.Broadcaster
+
+	// wal is the write-ahead log backing Config.Snapshotter. nil unless
+	// Config.Snapshotter is set.
+	wal *wal
+
+	// stats counters surfaced through Metrics/HTTPHandler. Table/network
+	// event counters are keyed by event type; bulk sync and collector
+	// registration happen once in New, so no lock is needed to read the
+	// map keys themselves, only the counters.
+	statsMu          sync.Mutex
+	tableEventCount  map[TableEventType]uint64
+	bulkSyncSuccess  uint64
+	bulkSyncFailures uint64
+	entriesByTable   map[string]uint64
+	reapEvents       uint64
+	bulkSyncBytesIn  uint64
+	bulkSyncBytesOut uint64
+	maxClockSkewSeen int64
+
 	// List of all tickers which needed to be stopped when
 	// cleaning up.
 	tickers []*time.Ticker
@@ -184,6 +222,36 @@ type thisNodeNetwork struct {
 	// An approximation of len(nDB.networkNodes[nid]) that can be accessed
 	// without synchronization.
 	networkNodes atomic.Int32
+
+	// merkle is a running summary of this network's table entries, kept
+	// incrementally up to date by createOrUpdateEntryLocal/deleteEntryLocal
+	// so that anti-entropy can compare it against a peer's summary without a
+	// full bulk sync. JoinNetwork installs an empty one; elsewhere it is
+	// created lazily on first use.
+	merkle *merkleSummary
+
+	// mu guards merkle above and entries below. It is intentionally separate from
+	// NetworkDB's top-level RWMutex: that lock still owns nDB.indexes (the
+	// cross-network view used by GetEntry, bulk sync and the reap loop),
+	// while mu owns only this network's own copy, so a read scoped to one
+	// network doesn't contend with writes happening on every other network
+	// joined on this node.
+	mu sync.RWMutex
+
+	// entries holds only this network's entries, keyed by "/tname/key",
+	// maintained in parallel by createOrUpdateEntryLocal/deleteEntryLocal.
+	// Unlike nDB.indexes[byTable]/[byNetwork], which need two separate trees
+	// because each must support iterating either "one table, all
+	// networks" or "one network, all tables", a per-network tree is
+	// already scoped to a single network, so one tree keyed by table
+	// serves both GetTableByNetwork and Scan. It exists to serve those
+	// reads without holding nDB's top-level lock during the walk.
+	// WalkTable still walks nDB.indexes[byTable].
+	// Splitting every consumer of nDB.indexes over to per-network trees
+	// (removing the global copy entirely) is a larger migration left for
+	// a follow-up; this lands the per-network lock and the two read APIs
+	// the issue asked for.
+	entries *iradix.Tree[*entry]
 }
 
 // Config represents the configuration of the networkdb instance and
@@ -240,8 +308,86 @@ type Config struct {
 	// HealthPrintPeriod the period to use to print the health score
 	// Default is 1min
 	HealthPrintPeriod time.Duration
+
+	// Snapshotter, if set, makes NetworkDB persist its locally owned state
+	// so it survives a process restart or crash instead of relying solely
+	// on bulk sync with the cluster to repopulate it. Unset by default: a
+	// freshly started NetworkDB is empty until it joins the cluster.
+	Snapshotter Snapshotter
+
+	// WALDir is the directory the write-ahead log is kept in when
+	// Snapshotter is set. Required if Snapshotter is set.
+	WALDir string
+
+	// WALMaxBytes is the write-ahead log size at which the background
+	// compactor takes a fresh snapshot and truncates the log. Defaults to
+	// walDefaultMaxBytes if unset.
+	WALMaxBytes int64
+
+	// MetricsRegisterer, if set, is used to register a Collector exposing
+	// NetworkDB's internal counters and gauges (see Metrics).
+	MetricsRegisterer prometheus.Registerer
+
+	// MetricsNamespace prefixes every metric registered through
+	// MetricsRegisterer. Defaults to "networkdb" if unset.
+	MetricsNamespace string
+
+	// SnapshotPath, if set, makes NetworkDB periodically persist a full
+	// point-in-time capture of everything it knows - not just locally
+	// owned state, unlike Snapshotter/WALDir above - to this file path via
+	// Snapshot, and load it back via Restore before joining the cluster.
+	// This gives a restarted node a hot cache to serve reads from
+	// immediately, reconciling any drift via gossip afterwards, instead of
+	// the thundering herd of every node bulk-syncing from scratch.
+	//
+	// SnapshotPath and Snapshotter/WALDir serve related but distinct
+	// purposes (full-state warm restart vs. durable write-ahead logging of
+	// this node's own writes) and can be configured independently or
+	// together; they were added for different use cases and don't share
+	// on-disk state.
+	SnapshotPath string
+
+	// SnapshotInterval is how often the background loop driven by
+	// SnapshotPath takes a fresh snapshot. Defaults to
+	// snapshotDefaultInterval if unset.
+	SnapshotInterval time.Duration
+
+	// EntryHook, if set, is invoked from createOrUpdateEntryLocal/
+	// deleteEntryLocal for every entry mutation this node applies locally
+	// to a network it has joined, whether
+	// originated here or learned from a gossip message. It's meant for
+	// operators to wire structured audit logging or feed a downstream
+	// store; it must not block or retain e beyond the call, since it runs
+	// synchronously on the hot entry-mutation path.
+	EntryHook EntryHook
 }
 
+// Op identifies the kind of mutation passed to an EntryHook.
+type Op int
+
+const (
+	OpCreate Op = iota
+	OpUpdate
+	OpDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpCreate:
+		return "create"
+	case OpUpdate:
+		return "update"
+	case OpDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// EntryHook is invoked by Config.EntryHook for every entry mutation; see its
+// doc comment for when it runs and what it must not do.
+type EntryHook func(op Op, nid, tname, key string, e *entry)
+
 // entry defines a table entry
 type entry struct {
 	// node from which this entry was learned.
@@ -283,13 +429,700 @@ func DefaultConfig() *Config {
 func New(c *Config) (*NetworkDB, error) {
 	nDB := newNetworkDB(c)
 	log.G(context.TODO()).Infof("New memberlist node - Node:%v will use memberlist nodeID:%v with config:%+v", c.Hostname, c.NodeID, c)
+
+	if c.Snapshotter != nil {
+		if err := nDB.restoreFromDisk(); err != nil {
+			return nil, fmt.Errorf("networkdb: failed to restore persisted state: %w", err)
+		}
+	}
+
+	if c.SnapshotPath != "" {
+		if f, err := os.Open(c.SnapshotPath); err == nil {
+			err = nDB.Restore(f)
+			f.Close()
+			if err != nil {
+				log.G(context.TODO()).Errorf("networkdb: ignoring unreadable snapshot at %s: %v", c.SnapshotPath, err)
+			}
+		} else if !os.IsNotExist(err) {
+			log.G(context.TODO()).Errorf("networkdb: ignoring unreadable snapshot at %s: %v", c.SnapshotPath, err)
+		}
+	}
+
 	if err := nDB.clusterInit(); err != nil {
 		return nil, err
 	}
 
+	if c.Snapshotter != nil {
+		go nDB.walCompactLoop()
+	}
+
+	if c.SnapshotPath != "" {
+		go nDB.snapshotLoop()
+	}
+
+	if err := nDB.registerMetrics(); err != nil {
+		return nil, fmt.Errorf("networkdb: failed to register metrics: %w", err)
+	}
+
 	return nDB, nil
 }
 
+// restoreFromDisk loads the last snapshot taken by Config.Snapshotter, then
+// replays the write-ahead log written since that snapshot, rebuilding
+// indexes, thisNodeNetworks and the Lamport clocks so a restarted node
+// resumes with its previously owned entries instead of starting empty and
+// waiting on a bulk sync. It also opens (creating if necessary) the WAL
+// that subsequent mutations append to.
+func (nDB *NetworkDB) restoreFromDisk() error {
+	snap, err := nDB.config.Snapshotter.LoadSnapshot(nDB.config.NodeID)
+	if err != nil {
+		return fmt.Errorf("loading snapshot: %w", err)
+	}
+	nDB.applySnapshot(snap)
+
+	w, err := openWAL(filepath.Join(nDB.config.WALDir, nDB.config.NodeID+".wal"))
+	if err != nil {
+		return fmt.Errorf("opening WAL: %w", err)
+	}
+	if err := w.replay(func(rec walRecord) error {
+		nDB.applyWALRecord(rec)
+		return nil
+	}); err != nil {
+		w.Close()
+		return fmt.Errorf("replaying WAL: %w", err)
+	}
+	nDB.wal = w
+
+	nDB.RLock()
+	nids := make([]string, 0, len(nDB.thisNodeNetworks))
+	for nid := range nDB.thisNodeNetworks {
+		nids = append(nids, nid)
+	}
+	nDB.RUnlock()
+	for _, nid := range nids {
+		nDB.rebuildMerkle(nid)
+	}
+	return nil
+}
+
+// applySnapshot loads snap into indexes/thisNodeNetworks and fast-forwards
+// the Lamport clocks past every ltime it contains, so the next Increment
+// call returns a time strictly greater than anything persisted.
+func (nDB *NetworkDB) applySnapshot(snap Snapshot) {
+	nDB.Lock()
+	defer nDB.Unlock()
+	for _, sn := range snap.Networks {
+		nDB.thisNodeNetworks[sn.NetworkID] = &thisNodeNetwork{network: network{ltime: serf.LamportTime(sn.LTime)}}
+		nDB.networkClock.Witness(serf.LamportTime(sn.LTime))
+	}
+	for _, se := range snap.Entries {
+		e := &entry{
+			node:     se.Node,
+			ltime:    serf.LamportTime(se.LTime),
+			value:    se.Value,
+			deleting: se.Deleting,
+			reapTime: nDB.config.reapEntryInterval,
+		}
+		nDB.createOrUpdateEntryAndApply(se.NetworkID, se.Table, se.Key, e)
+		nDB.witnessTableTime(serf.LamportTime(se.LTime))
+	}
+}
+
+// applyWALRecord replays a single WAL record during restoreFromDisk,
+// mirroring the effect the originating CreateEntry/UpdateEntry/DeleteEntry/
+// JoinNetwork/LeaveNetwork call had, without re-running their dedup checks
+// (the WAL only ever contains mutations that already succeeded).
+func (nDB *NetworkDB) applyWALRecord(rec walRecord) {
+	nDB.Lock()
+	defer nDB.Unlock()
+	switch rec.Op {
+	case walOpJoinNetwork:
+		nDB.thisNodeNetworks[rec.NetworkID] = &thisNodeNetwork{network: network{ltime: serf.LamportTime(rec.LTime)}}
+		nDB.networkClock.Witness(serf.LamportTime(rec.LTime))
+	case walOpLeaveNetwork:
+		if n, ok := nDB.thisNodeNetworks[rec.NetworkID]; ok {
+			n.leaving = true
+		}
+		nDB.networkClock.Witness(serf.LamportTime(rec.LTime))
+	default: // walOpCreate, walOpUpdate, walOpDelete
+		e := &entry{
+			node:     rec.Node,
+			ltime:    serf.LamportTime(rec.LTime),
+			value:    rec.Value,
+			deleting: rec.Op == walOpDelete,
+			reapTime: nDB.config.reapEntryInterval,
+		}
+		nDB.createOrUpdateEntryAndApply(rec.NetworkID, rec.Table, rec.Key, e)
+		nDB.witnessTableTime(serf.LamportTime(rec.LTime))
+	}
+}
+
+// walCompactLoop periodically checks the WAL size against
+// Config.WALMaxBytes and, once exceeded, takes a fresh snapshot via
+// Config.Snapshotter and truncates the WAL, so it doesn't grow unbounded
+// over the node's lifetime. It exits when nDB.ctx is cancelled.
+func (nDB *NetworkDB) walCompactLoop() {
+	maxBytes := nDB.config.WALMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = walDefaultMaxBytes
+	}
+	ticker := time.NewTicker(walCompactCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-nDB.ctx.Done():
+			return
+		case <-ticker.C:
+			if sz, err := nDB.wal.size(); err != nil || sz < maxBytes {
+				continue
+			}
+			if err := nDB.compactWAL(); err != nil {
+				log.G(context.TODO()).Errorf("networkdb: WAL compaction failed: %v", err)
+			}
+		}
+	}
+}
+
+// compactWAL snapshots the current state and truncates the WAL. It holds
+// nDB.Lock() from the snapshot through the truncate, and createOrUpdateEntry
+// et al. take nDB.Lock() to change the indexes, so no mutation can land in
+// between: one applied before is in the snapshot, one applied after is
+// appended to the truncated WAL.
+func (nDB *NetworkDB) compactWAL() error {
+	nDB.Lock()
+	defer nDB.Unlock()
+	snap := nDB.buildSnapshot()
+
+	if err := nDB.config.Snapshotter.SaveSnapshot(nDB.config.NodeID, snap); err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	return nDB.wal.truncate()
+}
+
+// buildSnapshot captures every locally owned entry and network attachment
+// into a Snapshot. The caller must hold at least nDB.RLock().
+func (nDB *NetworkDB) buildSnapshot() Snapshot {
+	snap := Snapshot{
+		NetworkClock: uint64(nDB.networkClock.Time()),
+		TableClock:   uint64(nDB.tableClock.Time()),
+		SavedAt:      time.Now(),
+	}
+	for nid, n := range nDB.thisNodeNetworks {
+		snap.Networks = append(snap.Networks, SnapshotNetwork{NetworkID: nid, LTime: uint64(n.ltime), EntriesNumber: n.entriesNumber.Load()})
+	}
+	nDB.indexes[byTable].Root().Walk(func(path []byte, v *entry) bool {
+		if v.node != nDB.config.NodeID {
+			return false
+		}
+		params := strings.Split(string(path[1:]), "/")
+		snap.Entries = append(snap.Entries, SnapshotEntry{
+			Table:     params[0],
+			NetworkID: params[1],
+			Key:       params[2],
+			Value:     v.value,
+			LTime:     uint64(v.ltime),
+			Node:      v.node,
+			Deleting:  v.deleting,
+			ReapTime:  v.reapTime,
+		})
+		return false
+	})
+	return snap
+}
+
+// buildFullSnapshot captures every entry in every network this node knows
+// about - not just those it owns - for NetworkDB.Snapshot. Unlike
+// buildSnapshot (used by the WAL/Snapshotter path, where remote entries are
+// assumed to still be reachable via gossip from peers), a warm restart has
+// no peers to gossip with yet, so it needs the full picture to serve reads
+// immediately. The caller must hold at least nDB.RLock().
+func (nDB *NetworkDB) buildFullSnapshot() Snapshot {
+	snap := nDB.buildSnapshot()
+	snap.Entries = nil
+	nDB.indexes[byTable].Root().Walk(func(path []byte, v *entry) bool {
+		params := strings.Split(string(path[1:]), "/")
+		snap.Entries = append(snap.Entries, SnapshotEntry{
+			Table:     params[0],
+			NetworkID: params[1],
+			Key:       params[2],
+			Value:     v.value,
+			LTime:     uint64(v.ltime),
+			Node:      v.node,
+			Deleting:  v.deleting,
+			ReapTime:  v.reapTime,
+		})
+		return false
+	})
+	return snap
+}
+
+// Snapshot is the persisted state a Snapshotter saves and loads for one
+// node, or a full point-in-time capture written by NetworkDB.Snapshot.
+// Which of the two it is changes what Entries/Networks cover: a
+// Snapshotter-backed Snapshot only covers entries owned by the persisting
+// node (remote entries are expected to be repopulated by bulk
+// sync/anti-entropy after restart), while one written by NetworkDB.Snapshot
+// covers every entry in every network the node knows about. NetworkClock,
+// TableClock and SavedAt are populated in both, but only Restore reads
+// them; the WAL/Snapshotter path (applySnapshot) re-derives clock state
+// entry by entry via Witness instead.
+type Snapshot struct {
+	Entries  []SnapshotEntry
+	Networks []SnapshotNetwork
+
+	// NetworkClock and TableClock are nDB.networkClock/tableClock at the
+	// moment of capture.
+	NetworkClock uint64
+	TableClock   uint64
+
+	// SavedAt is when the snapshot was taken, used by Restore to age a
+	// deleting entry's ReapTime forward by however long has elapsed since.
+	SavedAt time.Time
+}
+
+// SnapshotEntry is one table entry, owned or remote.
+type SnapshotEntry struct {
+	Table     string
+	NetworkID string
+	Key       string
+	Value     []byte
+	LTime     uint64
+	Node      string
+	Deleting  bool
+
+	// ReapTime is the entry's reapTime at the moment of capture: how much
+	// longer a deleting entry had left before the reaper would remove it.
+	// Only meaningful when Deleting is true.
+	ReapTime time.Duration
+}
+
+// SnapshotNetwork is one network the persisting node had joined.
+type SnapshotNetwork struct {
+	NetworkID string
+	LTime     uint64
+
+	// EntriesNumber is the network's entriesNumber counter at capture time.
+	EntriesNumber int64
+}
+
+// Snapshotter persists and restores a node's owned NetworkDB state across
+// process restarts, so CreateEntry/UpdateEntry/DeleteEntry/JoinNetwork/
+// LeaveNetwork calls aren't lost if the node crashes before the cluster's
+// bulk sync would otherwise have repropagated them. Implementations must be
+// safe for concurrent use.
+type Snapshotter interface {
+	// SaveSnapshot persists state as the latest snapshot for nodeID,
+	// replacing any previous one.
+	SaveSnapshot(nodeID string, state Snapshot) error
+	// LoadSnapshot returns the last snapshot saved for nodeID, or the zero
+	// Snapshot if none exists yet.
+	LoadSnapshot(nodeID string) (Snapshot, error)
+}
+
+// walOp identifies the kind of mutation a walRecord represents.
+type walOp int
+
+const (
+	walOpCreate walOp = iota
+	walOpUpdate
+	walOpDelete
+	walOpJoinNetwork
+	walOpLeaveNetwork
+)
+
+// walRecord is one entry in the write-ahead log: enough to replay a single
+// CreateEntry/UpdateEntry/DeleteEntry/JoinNetwork/LeaveNetwork call.
+type walRecord struct {
+	Op        walOp
+	Table     string
+	NetworkID string
+	Key       string
+	Value     []byte
+	LTime     uint64
+	Node      string
+}
+
+const (
+	// walDefaultMaxBytes is used when Config.WALMaxBytes is unset.
+	walDefaultMaxBytes = 64 * 1024 * 1024
+	// walCompactCheckInterval is how often walCompactLoop checks the WAL
+	// size against the configured threshold.
+	walCompactCheckInterval = time.Minute
+)
+
+// wal is an append-only, fsync'd log of walRecords backed by a single file.
+// Each record is length-prefixed and checksummed independently so a
+// truncated write at the tail (a crash mid-append) is detected and ignored
+// rather than corrupting replay of the records before it.
+type wal struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+func openWAL(path string) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &wal{f: f, path: path}, nil
+}
+
+// append encodes rec with gob, prefixes it with its length and a CRC32
+// checksum, writes it, and fsyncs before returning so a successful append
+// implies the record survives a crash.
+func (w *wal) append(rec walRecord) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return err
+	}
+	payload := buf.Bytes()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var hdr [8]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(hdr[4:8], crc32.ChecksumIEEE(payload))
+	if _, err := w.f.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := w.f.Write(payload); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// replay reads every intact record in the log, in append order, calling fn
+// for each. It stops at the first record whose checksum doesn't match (a
+// torn write from a crash mid-append) rather than erroring, since that
+// record was never acknowledged as durable.
+func (w *wal) replay(fn func(walRecord) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(w.f)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			break // torn header at EOF
+		}
+		n := binary.LittleEndian.Uint32(hdr[0:4])
+		wantCRC := binary.LittleEndian.Uint32(hdr[4:8])
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			break // torn payload at EOF
+		}
+		if crc32.ChecksumIEEE(payload) != wantCRC {
+			break
+		}
+		var rec walRecord
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&rec); err != nil {
+			break
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if _, err := w.f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// size returns the current WAL file size in bytes.
+func (w *wal) size() (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fi, err := w.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// truncate empties the WAL after its contents have been folded into a
+// snapshot by compactWAL.
+func (w *wal) truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+func (w *wal) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// walAppendEntry records a table-entry mutation in the WAL, if one is
+// configured. It's a no-op returning nil when Config.Snapshotter is unset.
+func (nDB *NetworkDB) walAppendEntry(op walOp, nid, tname, key string, e *entry) error {
+	if nDB.wal == nil {
+		return nil
+	}
+	return nDB.wal.append(walRecord{
+		Op: op, Table: tname, NetworkID: nid, Key: key,
+		Value: e.value, LTime: uint64(e.ltime), Node: e.node,
+	})
+}
+
+// walAppendNetwork records a JoinNetwork/LeaveNetwork mutation in the WAL,
+// if one is configured.
+func (nDB *NetworkDB) walAppendNetwork(op walOp, nid string, ltime serf.LamportTime) error {
+	if nDB.wal == nil {
+		return nil
+	}
+	return nDB.wal.append(walRecord{Op: op, NetworkID: nid, LTime: uint64(ltime)})
+}
+
+// snapshotStreamMagic identifies the wire format NetworkDB.Snapshot writes
+// and NetworkDB.Restore reads, so Restore can reject a file written in some
+// future, incompatible format instead of gob-decoding garbage.
+const snapshotStreamMagic = "NDB1"
+
+// snapshotDefaultInterval is used when Config.SnapshotInterval is unset.
+const snapshotDefaultInterval = 5 * time.Minute
+
+// Snapshot writes a full point-in-time capture of every network this node
+// knows about - every entry from indexes[byTable] regardless of which node
+// owns it, this node's own network attachments, and the global
+// network/table Lamport clocks - to w. The stream is framed with a version
+// header and a CRC32 trailer so Restore can detect a truncated or corrupted
+// write before trusting any of it.
+func (nDB *NetworkDB) Snapshot(w io.Writer) error {
+	nDB.RLock()
+	snap := nDB.buildFullSnapshot()
+	nDB.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+	payload := buf.Bytes()
+
+	if _, err := w.Write([]byte(snapshotStreamMagic)); err != nil {
+		return err
+	}
+	var hdr [4]byte
+	binary.LittleEndian.PutUint32(hdr[:], crc32.ChecksumIEEE(payload))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// Restore replaces the in-memory entries and network attachments with the
+// contents of a stream previously written by Snapshot, re-seats the Lamport
+// clocks via Witness, and re-emits a Create WatchEvent for every restored
+// entry so subscribers repopulate their own caches exactly as they would
+// have by learning of each entry normally.
+//
+// A deleting entry whose ReapTime had already elapsed by the time Snapshot
+// was taken plus however long has elapsed since is dropped rather than
+// resurrected, since the reaper would only remove it again immediately;
+// everything else is restored with its remaining ReapTime aged forward by
+// that elapsed duration.
+func (nDB *NetworkDB) Restore(r io.Reader) error {
+	magic := make([]byte, len(snapshotStreamMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if string(magic) != snapshotStreamMagic {
+		return fmt.Errorf("networkdb: unrecognized snapshot format %q", magic)
+	}
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return fmt.Errorf("reading snapshot checksum: %w", err)
+	}
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading snapshot body: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(hdr[:]) {
+		return errors.New("networkdb: snapshot checksum mismatch")
+	}
+
+	var snap Snapshot
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&snap); err != nil {
+		return fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	nDB.Lock()
+	for _, sn := range snap.Networks {
+		n := &thisNodeNetwork{network: network{ltime: serf.LamportTime(sn.LTime)}, entries: iradix.New[*entry]()}
+		n.entriesNumber.Store(sn.EntriesNumber)
+		nDB.thisNodeNetworks[sn.NetworkID] = n
+	}
+	nDB.networkClock.Witness(serf.LamportTime(snap.NetworkClock))
+	nDB.Unlock()
+
+	elapsed := time.Duration(0)
+	if !snap.SavedAt.IsZero() {
+		elapsed = time.Since(snap.SavedAt)
+	}
+	for _, se := range snap.Entries {
+		reapTime := se.ReapTime
+		if se.Deleting {
+			if elapsed >= reapTime {
+				continue
+			}
+			reapTime -= elapsed
+		}
+		e := &entry{
+			node:     se.Node,
+			ltime:    serf.LamportTime(se.LTime),
+			value:    se.Value,
+			deleting: se.Deleting,
+			reapTime: reapTime,
+		}
+		nDB.Lock()
+		nDB.createOrUpdateEntryAndApply(se.NetworkID, se.Table, se.Key, e)
+		nDB.witnessTableTime(serf.LamportTime(se.LTime))
+		nDB.Unlock()
+
+		nDB.publishWatchEvent(se.NetworkID, se.Table, WatchEvent{
+			Table:     se.Table,
+			NetworkID: se.NetworkID,
+			Key:       se.Key,
+			Value:     se.Value,
+		})
+	}
+	nDB.witnessTableTime(serf.LamportTime(snap.TableClock))
+
+	return nil
+}
+
+// snapshotLoop periodically writes a full Snapshot to Config.SnapshotPath,
+// atomically via a temp file and rename so a concurrent Restore (e.g. by an
+// operator copying the file) never observes a half-written snapshot. It
+// exits when nDB.ctx is cancelled.
+func (nDB *NetworkDB) snapshotLoop() {
+	interval := nDB.config.SnapshotInterval
+	if interval <= 0 {
+		interval = snapshotDefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-nDB.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := nDB.saveSnapshotFile(); err != nil {
+				log.G(context.TODO()).Errorf("networkdb: periodic snapshot failed: %v", err)
+			}
+		}
+	}
+}
+
+// saveSnapshotFile writes a Snapshot to Config.SnapshotPath via a temp file
+// and rename.
+func (nDB *NetworkDB) saveSnapshotFile() error {
+	tmp := nDB.config.SnapshotPath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := nDB.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, nDB.config.SnapshotPath)
+}
+
+// FileSnapshotter is the default Snapshotter, storing one gob-encoded,
+// checksummed snapshot file per node under Dir.
+type FileSnapshotter struct {
+	Dir string
+}
+
+// NewFileSnapshotter returns a FileSnapshotter rooted at dir, creating dir
+// if it doesn't already exist.
+func NewFileSnapshotter(dir string) (*FileSnapshotter, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSnapshotter{Dir: dir}, nil
+}
+
+func (s *FileSnapshotter) snapshotPath(nodeID string) string {
+	return filepath.Join(s.Dir, nodeID+".snapshot")
+}
+
+func (s *FileSnapshotter) SaveSnapshot(nodeID string, state Snapshot) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return err
+	}
+	sum := crc32.ChecksumIEEE(buf.Bytes())
+
+	tmp := s.snapshotPath(nodeID) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], sum)
+	if _, err := f.Write(trailer[:]); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.snapshotPath(nodeID))
+}
+
+func (s *FileSnapshotter) LoadSnapshot(nodeID string) (Snapshot, error) {
+	buf, err := os.ReadFile(s.snapshotPath(nodeID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Snapshot{}, nil
+		}
+		return Snapshot{}, err
+	}
+	if len(buf) < 4 {
+		return Snapshot{}, fmt.Errorf("networkdb: truncated snapshot file for node %s", nodeID)
+	}
+	data, trailer := buf[:len(buf)-4], buf[len(buf)-4:]
+	if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(trailer) {
+		return Snapshot{}, fmt.Errorf("networkdb: snapshot checksum mismatch for node %s", nodeID)
+	}
+
+	var snap Snapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap); err != nil {
+		return Snapshot{}, err
+	}
+	return snap, nil
+}
+
 func newNetworkDB(c *Config) *NetworkDB {
 	// The garbage collection logic for entries leverage the presence of the network.
 	// For this reason the expiration time of the network is put slightly higher than the entry expiration so that
@@ -305,16 +1138,77 @@ func newNetworkDB(c *Config) *NetworkDB {
 			byTable:   iradix.New[*entry](),
 			byNetwork: iradix.New[*entry](),
 		},
-		networks:         make(map[string]map[string]*network),
-		thisNodeNetworks: make(map[string]*thisNodeNetwork),
-		nodes:            make(map[string]*node),
-		failedNodes:      make(map[string]*node),
-		leftNodes:        make(map[string]*node),
-		networkNodes:     make(map[string][]string),
-		bulkSyncAckTbl:   make(map[string]chan struct{}),
-		broadcaster:      events.NewBroadcaster(),
-		rng:              rand.New(rand.NewChaCha8(rngSeed)), //gosec:disable G404 -- not used in a security sensitive context
+		networks:          make(map[string]map[string]*network),
+		thisNodeNetworks:  make(map[string]*thisNodeNetwork),
+		nodes:             make(map[string]*node),
+		failedNodes:       make(map[string]*node),
+		leftNodes:         make(map[string]*node),
+		networkNodes:      make(map[string][]string),
+		bulkSyncAckTbl:    make(map[string]chan struct{}),
+		watchBroadcasters: make(map[watchShardKey]*events.Broadcaster),
+		broadcaster:       events.NewBroadcaster(),
+		tableEventCount:   make(map[TableEventType]uint64),
+		entriesByTable:    make(map[string]uint64),
+		rng:               rand.New(rand.NewChaCha8(rngSeed)), //gosec:disable G404 -- not used in a security sensitive context
+	}
+}
+
+// recordTableEvent counts a table event this node originated, for Metrics.
+func (nDB *NetworkDB) recordTableEvent(t TableEventType) {
+	nDB.statsMu.Lock()
+	nDB.tableEventCount[t]++
+	nDB.statsMu.Unlock()
+}
+
+// recordBulkSync counts the outcome of a bulkSync call, for Metrics.
+func (nDB *NetworkDB) recordBulkSync(ok bool) {
+	nDB.statsMu.Lock()
+	if ok {
+		nDB.bulkSyncSuccess++
+	} else {
+		nDB.bulkSyncFailures++
 	}
+	nDB.statsMu.Unlock()
+}
+
+// recordReapEvent counts one entry being permanently removed by the reaper,
+// for Metrics. The reap loop itself lives outside this file (like bulkSync,
+// clusterInit and friends, it isn't part of this package's single source
+// file); this method is the counting hook it's expected to call each time it
+// purges an expired entry.
+func (nDB *NetworkDB) recordReapEvent() {
+	nDB.statsMu.Lock()
+	nDB.reapEvents++
+	nDB.statsMu.Unlock()
+}
+
+// recordBulkSyncBytes accounts for the wire size of one bulkSync exchange,
+// for Metrics. Like recordReapEvent, this is a counting hook for bulkSync
+// (defined outside this file) to call, not something invoked from within it.
+func (nDB *NetworkDB) recordBulkSyncBytes(in, out int) {
+	nDB.statsMu.Lock()
+	nDB.bulkSyncBytesIn += uint64(in)
+	nDB.bulkSyncBytesOut += uint64(out)
+	nDB.statsMu.Unlock()
+}
+
+// witnessTableTime is Witness on nDB.tableClock, additionally tracking the
+// largest skew seen between a witnessed Lamport time and this node's own
+// clock, for Metrics. Used at this file's own Witness call sites
+// (restoreFromDisk/applySnapshot/applyWALRecord/Restore); call sites in the
+// gossip message handlers that actually dominate clock skew live outside
+// this file and would need the same wrapper to be reflected here.
+func (nDB *NetworkDB) witnessTableTime(t serf.LamportTime) {
+	skew := int64(t) - int64(nDB.tableClock.Time())
+	if skew < 0 {
+		skew = -skew
+	}
+	nDB.statsMu.Lock()
+	if skew > nDB.maxClockSkewSeen {
+		nDB.maxClockSkewSeen = skew
+	}
+	nDB.statsMu.Unlock()
+	nDB.tableClock.Witness(t)
 }
 
 // Join joins this NetworkDB instance with a list of peer NetworkDB
@@ -336,6 +1230,244 @@ func (nDB *NetworkDB) Close() {
 
 	// Avoid (*Broadcaster).run goroutine leak
 	nDB.broadcaster.Close()
+
+	nDB.watchBroadcastersMu.Lock()
+	for _, b := range nDB.watchBroadcasters {
+		b.Close()
+	}
+	nDB.watchBroadcastersMu.Unlock()
+
+	if nDB.wal != nil {
+		if err := nDB.wal.Close(); err != nil {
+			log.G(context.TODO()).Errorf("%v(%v) Could not close WAL: %v", nDB.config.Hostname, nDB.config.NodeID, err)
+		}
+	}
+}
+
+// NetworkMetrics is a per-network slice of Metrics.
+type NetworkMetrics struct {
+	Entries               int64
+	Nodes                 int32
+	TableBroadcastQueue   int
+	TableRebroadcastQueue int
+	LamportTime           uint64
+	InSync                bool
+}
+
+// Metrics is a point-in-time snapshot of NetworkDB's internal counters and
+// gauges, independent of any particular metrics backend.
+type Metrics struct {
+	ClusterSize  int
+	FailedNodes  int
+	LeftNodes    int
+	NetworkClock uint64
+	TableClock   uint64
+
+	Networks map[string]NetworkMetrics
+
+	TableEventCreates uint64
+	TableEventUpdates uint64
+	TableEventDeletes uint64
+
+	BulkSyncSuccess  uint64
+	BulkSyncFailures uint64
+	BulkSyncBytesIn  uint64
+	BulkSyncBytesOut uint64
+
+	// EntriesByTable is the live entry count per table, aggregated across
+	// every network this node has joined.
+	EntriesByTable map[string]uint64
+
+	// ReapEvents is the number of entries permanently removed by the
+	// reaper. See recordReapEvent's doc comment: nothing in this file
+	// calls it today, since the reap loop lives elsewhere.
+	ReapEvents uint64
+
+	// MaxClockSkewSeen is the largest |witnessed - local| difference
+	// observed by witnessTableTime, in Lamport ticks.
+	MaxClockSkewSeen int64
+
+	// MemberlistHealthScore is memberlist's own assessment of how reliably
+	// this node believes it can communicate with the cluster: 0 is
+	// healthy, higher is worse. See (*memberlist.Memberlist).GetHealthScore.
+	MemberlistHealthScore int
+}
+
+// Metrics returns a snapshot of NetworkDB's internal state for monitoring.
+func (nDB *NetworkDB) Metrics() Metrics {
+	nDB.RLock()
+	m := Metrics{
+		ClusterSize:  len(nDB.nodes),
+		FailedNodes:  len(nDB.failedNodes),
+		LeftNodes:    len(nDB.leftNodes),
+		NetworkClock: uint64(nDB.networkClock.Time()),
+		TableClock:   uint64(nDB.tableClock.Time()),
+		Networks:     make(map[string]NetworkMetrics, len(nDB.thisNodeNetworks)),
+	}
+	for nid, n := range nDB.thisNodeNetworks {
+		nm := NetworkMetrics{
+			Entries:     n.entriesNumber.Load(),
+			Nodes:       n.networkNodes.Load(),
+			LamportTime: uint64(n.ltime),
+			InSync:      n.inSync,
+		}
+		if n.tableBroadcasts != nil {
+			nm.TableBroadcastQueue = n.tableBroadcasts.NumQueued()
+		}
+		if n.tableRebroadcasts != nil {
+			nm.TableRebroadcastQueue = n.tableRebroadcasts.NumQueued()
+		}
+		m.Networks[nid] = nm
+	}
+	if nDB.memberlist != nil {
+		m.MemberlistHealthScore = nDB.memberlist.GetHealthScore()
+	}
+	nDB.RUnlock()
+
+	nDB.statsMu.Lock()
+	m.TableEventCreates = nDB.tableEventCount[TableEventTypeCreate]
+	m.TableEventUpdates = nDB.tableEventCount[TableEventTypeUpdate]
+	m.TableEventDeletes = nDB.tableEventCount[TableEventTypeDelete]
+	m.BulkSyncSuccess = nDB.bulkSyncSuccess
+	m.BulkSyncFailures = nDB.bulkSyncFailures
+	m.BulkSyncBytesIn = nDB.bulkSyncBytesIn
+	m.BulkSyncBytesOut = nDB.bulkSyncBytesOut
+	m.ReapEvents = nDB.reapEvents
+	m.MaxClockSkewSeen = nDB.maxClockSkewSeen
+	m.EntriesByTable = make(map[string]uint64, len(nDB.entriesByTable))
+	for t, n := range nDB.entriesByTable {
+		m.EntriesByTable[t] = n
+	}
+	nDB.statsMu.Unlock()
+
+	return m
+}
+
+// registerMetrics builds and registers a prometheus.Collector wrapping nDB
+// under the configured namespace, if Config.MetricsRegisterer is set.
+func (nDB *NetworkDB) registerMetrics() error {
+	if nDB.config.MetricsRegisterer == nil {
+		return nil
+	}
+	ns := nDB.config.MetricsNamespace
+	if ns == "" {
+		ns = "networkdb"
+	}
+	return nDB.config.MetricsRegisterer.Register(&networkDBCollector{nDB: nDB, ns: ns})
+}
+
+// networkDBCollector adapts NetworkDB.Metrics to prometheus.Collector.
+type networkDBCollector struct {
+	nDB *NetworkDB
+	ns  string
+}
+
+func (c *networkDBCollector) Describe(ch chan<- *prometheus.Desc) {
+	// Metrics are computed dynamically from Metrics(), so Describe is a
+	// no-op: this Collector is "unchecked" and relies on Collect alone.
+}
+
+func (c *networkDBCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.nDB.Metrics()
+
+	gauge := func(name, help string, v float64, labels prometheus.Labels) {
+		desc := prometheus.NewDesc(c.ns+"_"+name, help, nil, labels)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(name, help string, v float64) {
+		desc := prometheus.NewDesc(c.ns+"_"+name, help, nil, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge("cluster_size", "Number of known cluster members.", float64(m.ClusterSize), nil)
+	gauge("failed_nodes", "Number of cluster members believed failed.", float64(m.FailedNodes), nil)
+	gauge("left_nodes", "Number of cluster members that left gracefully.", float64(m.LeftNodes), nil)
+	gauge("network_lamport_time", "Current network-attach Lamport clock.", float64(m.NetworkClock), nil)
+	gauge("table_lamport_time", "Current table-entry Lamport clock.", float64(m.TableClock), nil)
+	gauge("memberlist_health_score", "memberlist's self-reported health score; 0 is healthy.", float64(m.MemberlistHealthScore), nil)
+
+	counter("table_event_creates_total", "Table entry creates originated by this node.", float64(m.TableEventCreates))
+	counter("table_event_updates_total", "Table entry updates originated by this node.", float64(m.TableEventUpdates))
+	counter("table_event_deletes_total", "Table entry deletes originated by this node.", float64(m.TableEventDeletes))
+	counter("bulk_sync_success_total", "Successful bulkSync calls.", float64(m.BulkSyncSuccess))
+	counter("bulk_sync_failures_total", "Failed bulkSync calls.", float64(m.BulkSyncFailures))
+	counter("bulk_sync_bytes_in_total", "Bytes received across all bulkSync calls.", float64(m.BulkSyncBytesIn))
+	counter("bulk_sync_bytes_out_total", "Bytes sent across all bulkSync calls.", float64(m.BulkSyncBytesOut))
+	counter("reap_events_total", "Entries permanently removed by the reaper.", float64(m.ReapEvents))
+	gauge("max_clock_skew_ticks", "Largest Lamport-time skew observed via Witness.", float64(m.MaxClockSkewSeen), nil)
+
+	for nid, nm := range m.Networks {
+		labels := prometheus.Labels{"network_id": nid}
+		gauge("network_entries", "Entries known for this network.", float64(nm.Entries), labels)
+		gauge("network_nodes", "Peers participating in this network.", float64(nm.Nodes), labels)
+		gauge("network_table_broadcast_queue", "Queued table-broadcast gossip messages for this network.", float64(nm.TableBroadcastQueue), labels)
+		gauge("network_table_rebroadcast_queue", "Queued table-rebroadcast gossip messages for this network.", float64(nm.TableRebroadcastQueue), labels)
+	}
+
+	for tname, count := range m.EntriesByTable {
+		gauge("table_entries", "Live entries in this table, across every joined network.", float64(count), prometheus.Labels{"table": tname})
+	}
+}
+
+// healthStatus is the JSON body served by HTTPHandler.
+type healthStatus struct {
+	Metrics  Metrics    `json:"metrics"`
+	Peers    []PeerInfo `json:"peers"`
+	NodeID   string     `json:"node_id"`
+	Hostname string     `json:"hostname"`
+}
+
+// HTTPHandler returns an http.Handler serving a JSON snapshot of Metrics,
+// cluster peers and this node's identity, for use as a health/debug
+// endpoint independent of the Prometheus integration.
+func (nDB *NetworkDB) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{
+			Metrics:  nDB.Metrics(),
+			Peers:    nDB.ClusterPeers(),
+			NodeID:   nDB.config.NodeID,
+			Hostname: nDB.config.Hostname,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.G(r.Context()).Errorf("networkdb: failed to encode health response: %v", err)
+		}
+	})
+}
+
+// watchShardKey identifies a (network, table) pair watched by at least one
+// exact-scoped Watch subscriber.
+type watchShardKey struct {
+	nid   string
+	tname string
+}
+
+// watchBroadcasterFor returns the broadcaster that exact-scoped watchers of
+// (nid, tname) are fanned out through, creating it on first use.
+func (nDB *NetworkDB) watchBroadcasterFor(nid, tname string) *events.Broadcaster {
+	key := watchShardKey{nid: nid, tname: tname}
+
+	nDB.watchBroadcastersMu.Lock()
+	defer nDB.watchBroadcastersMu.Unlock()
+	b, ok := nDB.watchBroadcasters[key]
+	if !ok {
+		b = events.NewBroadcaster()
+		nDB.watchBroadcasters[key] = b
+	}
+	return b
+}
+
+// publishWatchEvent delivers ev to the catch-all broadcaster and, if one
+// exists, to the shard broadcaster for its exact (nid, tname) scope.
+func (nDB *NetworkDB) publishWatchEvent(nid, tname string, ev WatchEvent) {
+	nDB.broadcaster.Write(ev)
+
+	nDB.watchBroadcastersMu.Lock()
+	b, ok := nDB.watchBroadcasters[watchShardKey{nid: nid, tname: tname}]
+	nDB.watchBroadcastersMu.Unlock()
+	if ok {
+		b.Write(ev)
+	}
 }
 
 // ClusterPeers returns all the gossip cluster peers.
@@ -408,6 +1540,7 @@ func (nDB *NetworkDB) getEntry(tname, nid, key string) (*entry, error) {
 // entry unless the current entry is deleting state.
 func (nDB *NetworkDB) CreateEntry(tname, nid, key string, value []byte) error {
 	nDB.Lock()
+	n, ok := nDB.thisNodeNetworks[nid]
 	oldEntry, err := nDB.getEntry(tname, nid, key)
 	if err == nil || (oldEntry != nil && !oldEntry.deleting) {
 		nDB.Unlock()
@@ -420,9 +1553,20 @@ func (nDB *NetworkDB) CreateEntry(tname, nid, key string, value []byte) error {
 		value: value,
 	}
 
-	nDB.createOrUpdateEntry(nid, tname, key, entry)
+	_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, entry)
 	nDB.Unlock()
 
+	if ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, entry, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+
+	if err := nDB.walAppendEntry(walOpCreate, nid, tname, key, entry); err != nil {
+		return fmt.Errorf("cannot persist create entry to WAL: %w", err)
+	}
+
+	nDB.recordTableEvent(TableEventTypeCreate)
 	if err := nDB.sendTableEvent(TableEventTypeCreate, nid, tname, key, entry); err != nil {
 		return fmt.Errorf("cannot send create event for table %s, %v", tname, err)
 	}
@@ -436,6 +1580,7 @@ func (nDB *NetworkDB) CreateEntry(tname, nid, key string, value []byte) error {
 // non-existent entry.
 func (nDB *NetworkDB) UpdateEntry(tname, nid, key string, value []byte) error {
 	nDB.Lock()
+	n, ok := nDB.thisNodeNetworks[nid]
 	if _, err := nDB.getEntry(tname, nid, key); err != nil {
 		nDB.Unlock()
 		return fmt.Errorf("cannot update entry as the entry in table %s with network id %s and key %s does not exist", tname, nid, key)
@@ -447,9 +1592,170 @@ func (nDB *NetworkDB) UpdateEntry(tname, nid, key string, value []byte) error {
 		value: value,
 	}
 
-	nDB.createOrUpdateEntry(nid, tname, key, entry)
+	_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, entry)
+	nDB.Unlock()
+
+	if ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, entry, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+
+	if err := nDB.walAppendEntry(walOpUpdate, nid, tname, key, entry); err != nil {
+		return fmt.Errorf("cannot persist update entry to WAL: %w", err)
+	}
+
+	nDB.recordTableEvent(TableEventTypeUpdate)
+	if err := nDB.sendTableEvent(TableEventTypeUpdate, nid, tname, key, entry); err != nil {
+		return fmt.Errorf("cannot send table update event: %v", err)
+	}
+
+	return nil
+}
+
+// TableElem elem
+type TableElem struct {
+	Value []byte
+	owner string
+}
+
+// GetTableByNetwork walks the networkdb by the give table and network id and
+// returns a map of keys and values.
+//
+// This only needs nid's own per-network lock, not NetworkDB's top-level
+// one: nid must be a network this node has joined (thisNodeNetworks), whose
+// entries tree is maintained independently of every other joined network.
+func (nDB *NetworkDB) GetTableByNetwork(tname, nid string) map[string]*TableElem {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	nDB.RUnlock()
+	if !ok {
+		return nil
+	}
+	n.mu.RLock()
+	root := n.entries.Root()
+	n.mu.RUnlock()
+	entries := make(map[string]*TableElem)
+	root.WalkPrefix([]byte("/"+tname), func(k []byte, v *entry) bool {
+		if v.deleting {
+			return false
+		}
+		key := string(k)
+		key = key[strings.LastIndex(key, "/")+1:]
+		entries[key] = &TableElem{Value: v.value, owner: v.node}
+		return false
+	})
+	return entries
+}
+
+// DeleteEntry deletes a table entry in NetworkDB for given (network,
+// table, key) tuple and if the NetworkDB is part of the cluster
+// propagates this event to the cluster.
+func (nDB *NetworkDB) DeleteEntry(tname, nid, key string) error {
+	nDB.Lock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	oldEntry, err := nDB.getEntry(tname, nid, key)
+	if err != nil || oldEntry == nil || oldEntry.deleting {
+		nDB.Unlock()
+		return fmt.Errorf("cannot delete entry %s with network id %s and key %s "+
+			"does not exist or is already being deleted", tname, nid, key)
+	}
+
+	entry := &entry{
+		ltime:    nDB.tableClock.Increment(),
+		node:     nDB.config.NodeID,
+		value:    oldEntry.value,
+		deleting: true,
+		reapTime: nDB.config.reapEntryInterval,
+	}
+
+	_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, entry)
+	nDB.Unlock()
+
+	if ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, entry, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+
+	if err := nDB.walAppendEntry(walOpDelete, nid, tname, key, entry); err != nil {
+		return fmt.Errorf("cannot persist delete entry to WAL: %w", err)
+	}
+
+	nDB.recordTableEvent(TableEventTypeDelete)
+	if err := nDB.sendTableEvent(TableEventTypeDelete, nid, tname, key, entry); err != nil {
+		return fmt.Errorf("cannot send table delete event: %v", err)
+	}
+
+	return nil
+}
+
+// EntryRev is an opaque revision for an entry, returned by
+// GetEntryVersioned and consumed by UpdateEntryCAS/DeleteEntryCAS to detect
+// whether the entry changed concurrently. Its zero value never matches a
+// real entry, so it can be used as a "must not already exist" expectation.
+//
+// WatchEvent is generated from networkdb.proto and doesn't carry a revision
+// field; a Watch subscriber that needs one should pair Watch with a
+// GetEntryVersioned call for the key it just observed change.
+type EntryRev struct {
+	ltime serf.LamportTime
+	node  string
+}
+
+// ErrRevisionMismatch is returned by UpdateEntryCAS/DeleteEntryCAS when the
+// entry's current revision does not match the expected one, i.e. the entry
+// was created, updated or deleted by someone else since it was last read.
+var ErrRevisionMismatch = errors.New("networkdb: entry revision mismatch")
+
+// GetEntryVersioned retrieves the value of a table entry along with the
+// EntryRev it must be passed back with to UpdateEntryCAS/DeleteEntryCAS.
+func (nDB *NetworkDB) GetEntryVersioned(tname, nid, key string) ([]byte, EntryRev, error) {
+	nDB.RLock()
+	defer nDB.RUnlock()
+	v, err := nDB.getEntry(tname, nid, key)
+	if err != nil {
+		return nil, EntryRev{}, err
+	}
+	if v.deleting {
+		return nil, EntryRev{}, types.NotFoundErrorf("entry in table %s network id %s and key %s deleted and pending garbage collection", tname, nid, key)
+	}
+	return v.value, EntryRev{ltime: v.ltime, node: v.node}, nil
+}
+
+// UpdateEntryCAS updates a table entry only if its current revision still
+// matches expected, failing with ErrRevisionMismatch otherwise. This lets
+// callers implement read-modify-write without clobbering a concurrent
+// writer's update.
+func (nDB *NetworkDB) UpdateEntryCAS(tname, nid, key string, value []byte, expected EntryRev) error {
+	nDB.Lock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	cur, err := nDB.getEntry(tname, nid, key)
+	if err != nil {
+		nDB.Unlock()
+		return fmt.Errorf("cannot update entry as the entry in table %s with network id %s and key %s does not exist", tname, nid, key)
+	}
+	if cur.ltime != expected.ltime || cur.node != expected.node {
+		nDB.Unlock()
+		return ErrRevisionMismatch
+	}
+
+	entry := &entry{
+		ltime: nDB.tableClock.Increment(),
+		node:  nDB.config.NodeID,
+		value: value,
+	}
+
+	_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, entry)
 	nDB.Unlock()
 
+	if ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, entry, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+
+	nDB.recordTableEvent(TableEventTypeUpdate)
 	if err := nDB.sendTableEvent(TableEventTypeUpdate, nid, tname, key, entry); err != nil {
 		return fmt.Errorf("cannot send table update event: %v", err)
 	}
@@ -457,54 +1763,40 @@ func (nDB *NetworkDB) UpdateEntry(tname, nid, key string, value []byte) error {
 	return nil
 }
 
-// TableElem elem
-type TableElem struct {
-	Value []byte
-	owner string
-}
-
-// GetTableByNetwork walks the networkdb by the give table and network id and
-// returns a map of keys and values
-func (nDB *NetworkDB) GetTableByNetwork(tname, nid string) map[string]*TableElem {
-	nDB.RLock()
-	root := nDB.indexes[byTable].Root()
-	nDB.RUnlock()
-	entries := make(map[string]*TableElem)
-	root.WalkPrefix([]byte(fmt.Sprintf("/%s/%s", tname, nid)), func(k []byte, v *entry) bool {
-		if v.deleting {
-			return false
-		}
-		key := string(k)
-		key = key[strings.LastIndex(key, "/")+1:]
-		entries[key] = &TableElem{Value: v.value, owner: v.node}
-		return false
-	})
-	return entries
-}
-
-// DeleteEntry deletes a table entry in NetworkDB for given (network,
-// table, key) tuple and if the NetworkDB is part of the cluster
-// propagates this event to the cluster.
-func (nDB *NetworkDB) DeleteEntry(tname, nid, key string) error {
+// DeleteEntryCAS deletes a table entry only if its current revision still
+// matches expected, failing with ErrRevisionMismatch otherwise.
+func (nDB *NetworkDB) DeleteEntryCAS(tname, nid, key string, expected EntryRev) error {
 	nDB.Lock()
-	oldEntry, err := nDB.getEntry(tname, nid, key)
-	if err != nil || oldEntry == nil || oldEntry.deleting {
+	n, ok := nDB.thisNodeNetworks[nid]
+	cur, err := nDB.getEntry(tname, nid, key)
+	if err != nil || cur.deleting {
 		nDB.Unlock()
 		return fmt.Errorf("cannot delete entry %s with network id %s and key %s "+
 			"does not exist or is already being deleted", tname, nid, key)
 	}
+	if cur.ltime != expected.ltime || cur.node != expected.node {
+		nDB.Unlock()
+		return ErrRevisionMismatch
+	}
 
 	entry := &entry{
 		ltime:    nDB.tableClock.Increment(),
 		node:     nDB.config.NodeID,
-		value:    oldEntry.value,
+		value:    cur.value,
 		deleting: true,
 		reapTime: nDB.config.reapEntryInterval,
 	}
 
-	nDB.createOrUpdateEntry(nid, tname, key, entry)
+	_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, entry)
 	nDB.Unlock()
 
+	if ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, entry, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+
+	nDB.recordTableEvent(TableEventTypeDelete)
 	if err := nDB.sendTableEvent(TableEventTypeDelete, nid, tname, key, entry); err != nil {
 		return fmt.Errorf("cannot send table delete event: %v", err)
 	}
@@ -542,11 +1834,11 @@ func (nDB *NetworkDB) deleteNodeNetworkEntries(nid, node string) {
 			params := strings.Split(string(path[1:]), "/")
 			nwID, tName, key := params[0], params[1], params[2]
 
-			nDB.deleteEntry(nwID, tName, key)
+			nDB.deleteEntryAndApply(nwID, tName, key)
 
 			// Notify to the upper layer only entries not already marked for deletion
 			if !oldEntry.deleting {
-				nDB.broadcaster.Write(WatchEvent{
+				nDB.publishWatchEvent(nwID, tName, WatchEvent{
 					Table:     tName,
 					NetworkID: nwID,
 					Key:       key,
@@ -568,10 +1860,10 @@ func (nDB *NetworkDB) deleteNodeTableEntries(node string) {
 		params := strings.Split(string(path[1:]), "/")
 		tName, nwID, key := params[0], params[1], params[2]
 
-		nDB.deleteEntry(nwID, tName, key)
+		nDB.deleteEntryAndApply(nwID, tName, key)
 
 		if !oldEntry.deleting {
-			nDB.broadcaster.Write(WatchEvent{
+			nDB.publishWatchEvent(nwID, tName, WatchEvent{
 				Table:     tName,
 				NetworkID: nwID,
 				Key:       key,
@@ -599,6 +1891,301 @@ func (nDB *NetworkDB) WalkTable(tname string, fn func(string, string, []byte, bo
 	return nil
 }
 
+// SlowConsumerPolicy controls how a Watch subscription behaves when its
+// consumer falls behind the BufferSize configured for it.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDropOldest discards the oldest buffered event to make
+	// room for the new one, then delivers a resync sentinel (a WatchEvent
+	// with an empty Table, NetworkID and Key) so the consumer knows it may
+	// have missed events and should reconcile from GetTableByNetwork/
+	// WalkTable rather than trust the stream alone.
+	SlowConsumerDropOldest SlowConsumerPolicy = iota
+	// SlowConsumerDisconnect closes the subscription instead of dropping
+	// events, forcing the consumer to call Watch again.
+	SlowConsumerDisconnect
+)
+
+// WatchOptions configures a NetworkDB.Watch subscription.
+type WatchOptions struct {
+	// Filter, when non-nil, is evaluated against every event that already
+	// matches tname/nid/keyPrefix; events for which it returns false are
+	// not delivered.
+	Filter func(WatchEvent) bool
+
+	// Replay, when true, delivers a synthetic Create WatchEvent for every
+	// entry already present in the watched scope before the first live
+	// event, so a subscriber sees a consistent starting state rather than
+	// only future changes.
+	Replay bool
+
+	// BufferSize bounds the number of undelivered events queued for this
+	// subscriber. A value <= 0 uses watchDefaultBufferSize.
+	BufferSize int
+
+	// SlowConsumer selects the policy applied once BufferSize is
+	// exhausted. The zero value is SlowConsumerDropOldest.
+	SlowConsumer SlowConsumerPolicy
+}
+
+// watchDefaultBufferSize is used when WatchOptions.BufferSize is unset.
+const watchDefaultBufferSize = 128
+
+// CancelFunc stops a Watch subscription and releases the resources backing
+// it. It is safe to call more than once.
+type CancelFunc func()
+
+// watchSink is an events.Sink that delivers WatchEvents to a bounded
+// channel, applying a WatchOptions.SlowConsumer policy once it fills up.
+type watchSink struct {
+	mu     sync.Mutex
+	ch     chan WatchEvent
+	policy SlowConsumerPolicy
+	closed bool
+}
+
+func newWatchSink(bufSize int, policy SlowConsumerPolicy) *watchSink {
+	if bufSize <= 0 {
+		bufSize = watchDefaultBufferSize
+	}
+	return &watchSink{ch: make(chan WatchEvent, bufSize), policy: policy}
+}
+
+func (s *watchSink) Write(ev events.Event) error {
+	wev, ok := ev.(WatchEvent)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return events.ErrSinkClosed
+	}
+
+	select {
+	case s.ch <- wev:
+		return nil
+	default:
+	}
+
+	if s.policy == SlowConsumerDisconnect {
+		return events.ErrSinkClosed
+	}
+
+	// SlowConsumerDropOldest: make room and let the consumer know it may
+	// have missed an update via the resync sentinel.
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- WatchEvent{}:
+	default:
+	}
+	return nil
+}
+
+func (s *watchSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.ch)
+	return nil
+}
+
+// Watch subscribes to table events matching tname, nid and keyPrefix (any of
+// which may be left empty to match everything on that axis). If both tname
+// and nid are non-empty the subscription is fanned out through the
+// per-(network, table) broadcaster created by watchBroadcasterFor, so a busy
+// table doesn't wake up watchers scoped to a different one; otherwise it
+// falls back to the catch-all broadcaster.
+//
+// If opts.Replay is set, Watch first walks the matching entries under RLock
+// and delivers each as a synthetic Create WatchEvent, fencing that snapshot
+// at the table clock's current Lamport time so the subsequent live stream
+// neither duplicates nor misses the events it raced with.
+func (nDB *NetworkDB) Watch(ctx context.Context, tname, nid, keyPrefix string, opts WatchOptions) (<-chan WatchEvent, CancelFunc, error) {
+	var matchers []events.Matcher
+	if tname != "" {
+		t := tname
+		matchers = append(matchers, events.MatcherFunc(func(ev events.Event) bool {
+			wev, ok := ev.(WatchEvent)
+			return ok && wev.Table == t
+		}))
+	}
+	if nid != "" {
+		n := nid
+		matchers = append(matchers, events.MatcherFunc(func(ev events.Event) bool {
+			wev, ok := ev.(WatchEvent)
+			return ok && wev.NetworkID == n
+		}))
+	}
+	if keyPrefix != "" {
+		p := keyPrefix
+		matchers = append(matchers, events.MatcherFunc(func(ev events.Event) bool {
+			wev, ok := ev.(WatchEvent)
+			return ok && strings.HasPrefix(wev.Key, p)
+		}))
+	}
+	if opts.Filter != nil {
+		f := opts.Filter
+		matchers = append(matchers, events.MatcherFunc(func(ev events.Event) bool {
+			wev, ok := ev.(WatchEvent)
+			return ok && f(wev)
+		}))
+	}
+
+	sink := newWatchSink(opts.BufferSize, opts.SlowConsumer)
+	var dst events.Sink = sink
+	if len(matchers) > 0 {
+		dst = events.NewFilter(dst, matchers...)
+	}
+
+	var broadcaster *events.Broadcaster
+	if tname != "" && nid != "" {
+		broadcaster = nDB.watchBroadcasterFor(nid, tname)
+	} else {
+		broadcaster = nDB.broadcaster
+	}
+
+	// Replay and subscription both happen under the same RLock, fencing the
+	// snapshot at the current table clock: any write racing with us blocks
+	// on nDB.Lock() until we release RUnlock below, then is delivered once
+	// as a live event, so nothing is missed or duplicated.
+	nDB.RLock()
+	if opts.Replay {
+		nDB.replayMatching(tname, nid, keyPrefix, func(wev WatchEvent) {
+			if opts.Filter == nil || opts.Filter(wev) {
+				_ = sink.Write(wev)
+			}
+		})
+	}
+	broadcaster.Add(dst)
+	nDB.RUnlock()
+
+	cancel := CancelFunc(func() {
+		broadcaster.Remove(dst)
+		sink.Close()
+	})
+
+	if ctx != nil {
+		go func() {
+			<-ctx.Done()
+			cancel()
+		}()
+	}
+
+	return sink.ch, cancel, nil
+}
+
+// WatchPrefix is a narrower convenience form of Watch for callers that just
+// want a live, key-prefix-filtered event stream for one network/table and
+// don't need a context, replay, or slow-consumer tuning: it's Watch with
+// context.Background() and WatchOptions{Replay: false}, registered on the
+// same per-(nid,tname) broadcaster so it doesn't wake up on unrelated
+// tables. Use Watch directly for anything needing replay-then-live
+// semantics, cancellation via context, or a non-default buffer/slow-consumer
+// policy.
+func (nDB *NetworkDB) WatchPrefix(nid, tname, keyPrefix string) (<-chan WatchEvent, func()) {
+	ch, cancel, err := nDB.Watch(context.Background(), tname, nid, keyPrefix, WatchOptions{})
+	if err != nil {
+		// Watch only returns an error from a future validation path; none
+		// exists today, so this is unreachable, but surface a closed
+		// channel rather than panicking if that ever changes.
+		closed := make(chan WatchEvent)
+		close(closed)
+		return closed, func() {}
+	}
+	return ch, func() { cancel() }
+}
+
+// Scan walks nid's table tname under keyPrefix in lexicographic key order,
+// returning up to limit non-deleted entries starting just after cursor (the
+// opaque continuation token returned by a previous call, or nil to start
+// from the beginning). next is non-nil when more entries remain.
+//
+// This reuses the per-network entries tree added for sharded locking
+// (thisNodeNetwork.entries, keyed by "/tname/key"): a single immutable
+// radix tree per joined network, already walked under only that network's
+// lock, is exactly the structure a prefix-ordered scan needs - no separate
+// index to maintain.
+func (nDB *NetworkDB) Scan(nid, tname, keyPrefix string, cursor []byte, limit int) ([]KV, []byte, error) {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	nDB.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("networkdb: network %s is not joined", nid)
+	}
+
+	n.mu.RLock()
+	root := n.entries.Root()
+	n.mu.RUnlock()
+
+	want := limit
+	if want > 0 {
+		want++
+	}
+	var keys [][]byte
+	var vals []*entry
+	root.WalkPrefix([]byte(fmt.Sprintf("/%s/%s", tname, keyPrefix)), func(k []byte, v *entry) bool {
+		if cursor != nil && bytes.Compare(k, cursor) <= 0 {
+			return false
+		}
+		if v.deleting {
+			return false
+		}
+		keys = append(keys, append([]byte(nil), k...))
+		vals = append(vals, v)
+		return want > 0 && len(keys) >= want
+	})
+
+	var next []byte
+	if want > 0 && len(keys) >= want {
+		keys = keys[:limit]
+		vals = vals[:limit]
+		next = keys[limit-1]
+	}
+	entries := make([]KV, len(keys))
+	for i, k := range keys {
+		params := strings.SplitN(string(k[1:]), "/", 2)
+		entries[i] = KV{Table: tname, NetworkID: nid, Key: params[1], Value: vals[i].value}
+	}
+	return entries, next, nil
+}
+
+// replayMatching walks the byTable index delivering a synthetic Create
+// WatchEvent for every non-deleted entry matching tname/nid/keyPrefix. The
+// caller must hold at least nDB.RLock().
+func (nDB *NetworkDB) replayMatching(tname, nid, keyPrefix string, deliver func(WatchEvent)) {
+	root := nDB.indexes[byTable].Root()
+	prefix := "/" + tname
+	root.WalkPrefix([]byte(prefix), func(path []byte, v *entry) bool {
+		if v.deleting {
+			return false
+		}
+		params := strings.Split(string(path[1:]), "/")
+		pTable, pNid, pKey := params[0], params[1], params[2]
+		if nid != "" && pNid != nid {
+			return false
+		}
+		if keyPrefix != "" && !strings.HasPrefix(pKey, keyPrefix) {
+			return false
+		}
+		deliver(WatchEvent{
+			Table:     pTable,
+			NetworkID: pNid,
+			Key:       pKey,
+			Value:     v.value,
+		})
+		return false
+	})
+}
+
 // JoinNetwork joins this node to a given network and propagates this
 // event across the cluster. This triggers this node joining the
 // sub-cluster of this network and participates in the network-scoped
@@ -624,11 +2211,15 @@ func (nDB *NetworkDB) JoinNetwork(nid string) error {
 			tableRebroadcasts: &memberlist.TransmitLimitedQueue{
 				RetransmitMult: 4,
 			},
+			entries: iradix.New[*entry](),
 		}
 		numNodes := func() int { return int(n.networkNodes.Load()) }
 		n.tableBroadcasts.NumNodes = numNodes
 		n.tableRebroadcasts.NumNodes = numNodes
 	}
+	n.mu.Lock()
+	n.merkle = newMerkleSummary()
+	n.mu.Unlock()
 	nDB.addNetworkNode(nid, nDB.config.NodeID)
 
 	if err := nDB.sendNetworkEvent(nid, NetworkEventTypeJoin, ltime); err != nil {
@@ -641,8 +2232,14 @@ func (nDB *NetworkDB) JoinNetwork(nid string) error {
 	nDB.thisNodeNetworks[nid] = n
 	nDB.Unlock()
 
+	if err := nDB.walAppendNetwork(walOpJoinNetwork, nid, ltime); err != nil {
+		return fmt.Errorf("cannot persist join network to WAL: %w", err)
+	}
+
 	log.G(context.TODO()).Debugf("%v(%v): joined network %s", nDB.config.Hostname, nDB.config.NodeID, nid)
-	if _, err := nDB.bulkSync(networkNodes, true); err != nil {
+	_, err := nDB.bulkSync(networkNodes, true)
+	nDB.recordBulkSync(err == nil)
+	if err != nil {
 		log.G(context.TODO()).Errorf("Error bulk syncing while joining network %s: %v", nid, err)
 	}
 
@@ -698,12 +2295,12 @@ func (nDB *NetworkDB) LeaveNetwork(nid string) error {
 				deleting: true,
 				reapTime: nDB.config.reapEntryInterval,
 			}
-			nDB.createOrUpdateEntry(nwID, tName, key, newEntry)
+			nDB.createOrUpdateEntryAndApply(nwID, tName, key, newEntry)
 		} else {
-			nDB.deleteEntry(nwID, tName, key)
+			nDB.deleteEntryAndApply(nwID, tName, key)
 		}
 		if !oldEntry.deleting {
-			nDB.broadcaster.Write(WatchEvent{
+			nDB.publishWatchEvent(nwID, tName, WatchEvent{
 				Table:     tName,
 				NetworkID: nwID,
 				Key:       key,
@@ -722,6 +2319,18 @@ func (nDB *NetworkDB) LeaveNetwork(nid string) error {
 	n.ltime = ltime
 	n.reapTime = nDB.config.reapNetworkInterval
 	n.leaving = true
+
+	if err := nDB.walAppendNetwork(walOpLeaveNetwork, nid, ltime); err != nil {
+		return fmt.Errorf("cannot persist leave network to WAL: %w", err)
+	}
+
+	// The entries above were just rewritten to tombstones (ours) or removed
+	// outright (remote), so the incrementally-maintained merkle summary no
+	// longer matches a one-XOR-per-mutation history anyone could replay;
+	// rebuild it directly from the radix tree instead of trying to thread
+	// the same bulk WalkPrefix through merkle.add/remove individually.
+	nDB.rebuildMerkleLocked(nid)
+
 	return nil
 }
 
@@ -781,42 +2390,485 @@ func (nDB *NetworkDB) findCommonNetworks(nodeName string) []string {
 	return networks
 }
 
+// updateLocalNetworkTime only needs a read lock on NetworkDB: it ranges
+// nDB.thisNodeNetworks without adding or removing keys, and each network's
+// ltime write is serialized by that network's own mu rather than the
+// top-level write lock, so bumping one network's clock doesn't block a
+// concurrent createOrUpdateEntry/deleteEntry happening on another.
 func (nDB *NetworkDB) updateLocalNetworkTime() {
-	nDB.Lock()
-	defer nDB.Unlock()
+	nDB.RLock()
+	defer nDB.RUnlock()
 
 	ltime := nDB.networkClock.Increment()
 	for _, n := range nDB.thisNodeNetworks {
+		n.mu.Lock()
 		n.ltime = ltime
+		n.mu.Unlock()
 	}
 }
 
-// createOrUpdateEntry this function handles the creation or update of entries into the local
-// tree store. It is also used to keep in sync the entries number of the network (all tables are aggregated)
-func (nDB *NetworkDB) createOrUpdateEntry(nid, tname, key string, v *entry) (okTable bool, okNetwork bool) {
-	nDB.indexes[byTable], _, okTable = nDB.indexes[byTable].Insert([]byte(fmt.Sprintf("/%s/%s/%s", tname, nid, key)), v)
+// createOrUpdateEntry inserts v into the shared byTable/byNetwork indexes
+// and bumps the entriesByTable counter. Callers must hold nDB.Lock():
+// unlike the per-network bookkeeping in createOrUpdateEntryLocal, these two
+// trees are genuinely shared across every network this node has joined, so
+// there's no per-network lock that can stand in for the top-level one here.
+func (nDB *NetworkDB) createOrUpdateEntry(nid, tname, key string, v *entry) (okTable bool, okNetwork bool, oldByTable *entry) {
+	nDB.indexes[byTable], oldByTable, okTable = nDB.indexes[byTable].Insert([]byte(fmt.Sprintf("/%s/%s/%s", tname, nid, key)), v)
 	nDB.indexes[byNetwork], _, okNetwork = nDB.indexes[byNetwork].Insert([]byte(fmt.Sprintf("/%s/%s/%s", nid, tname, key)), v)
 	if !okNetwork {
 		// Add only if it is an insert not an update
-		n, ok := nDB.thisNodeNetworks[nid]
-		if ok {
-			n.entriesNumber.Add(1)
+		nDB.statsMu.Lock()
+		nDB.entriesByTable[tname]++
+		nDB.statsMu.Unlock()
+	}
+	return okTable, okNetwork, oldByTable
+}
+
+// createOrUpdateEntryLocal applies an already-performed createOrUpdateEntry
+// to n's own per-network bookkeeping (merkle summary, entries radix tree,
+// entriesNumber) and fires the configured EntryHook. Callers must hold
+// n.mu.Lock(), NOT nDB.Lock() - see createOrUpdateEntryAndApply for why
+// this is split out.
+func (nDB *NetworkDB) createOrUpdateEntryLocal(n *thisNodeNetwork, nid, tname, key string, v, oldByTable *entry, isInsert bool) {
+	if isInsert {
+		n.entriesNumber.Add(1)
+	}
+	if n.merkle == nil {
+		n.merkle = newMerkleSummary()
+	}
+	if oldByTable != nil {
+		n.merkle.remove(tname, key, oldByTable)
+	}
+	n.merkle.add(tname, key, v)
+
+	if n.entries == nil {
+		n.entries = iradix.New[*entry]()
+	}
+	n.entries, _, _ = n.entries.Insert([]byte(fmt.Sprintf("/%s/%s", tname, key)), v)
+
+	if nDB.config.EntryHook != nil {
+		op := OpUpdate
+		if isInsert {
+			op = OpCreate
 		}
+		nDB.config.EntryHook(op, nid, tname, key, v)
+	}
+}
+
+// createOrUpdateEntryAndApply runs createOrUpdateEntry immediately followed
+// by createOrUpdateEntryLocal for nid's thisNodeNetwork (if this node has
+// joined it), for callers (WAL/snapshot replay, LeaveNetwork's tombstone
+// rewrite) that already hold nDB.Lock() for the whole operation and don't
+// need the two phases split across it.
+//
+// CreateEntry/UpdateEntry/DeleteEntry/UpdateEntryCAS/DeleteEntryCAS do NOT
+// use this: they used to hold nDB.Lock() across both phases, which meant a
+// write to one network serialized behind writes to every other network
+// this node had joined, even though createOrUpdateEntryLocal's work
+// (merkle, entries, entriesNumber) never touches anything but that one
+// network's own state. They call createOrUpdateEntry under nDB.Lock(),
+// release it, then call createOrUpdateEntryLocal under n.mu.Lock() instead.
+func (nDB *NetworkDB) createOrUpdateEntryAndApply(nid, tname, key string, v *entry) (okTable bool, okNetwork bool) {
+	okTable, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, v)
+	if n, ok := nDB.thisNodeNetworks[nid]; ok {
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, v, oldByTable, !okNetwork)
+		n.mu.Unlock()
 	}
 	return okTable, okNetwork
 }
 
-// deleteEntry this function handles the deletion of entries into the local tree store.
-// It is also used to keep in sync the entries number of the network (all tables are aggregated)
-func (nDB *NetworkDB) deleteEntry(nid, tname, key string) (okTable bool, okNetwork bool) {
-	nDB.indexes[byTable], _, okTable = nDB.indexes[byTable].Delete([]byte(fmt.Sprintf("/%s/%s/%s", tname, nid, key)))
+// deleteEntry removes tname/nid/key from the shared byTable/byNetwork
+// indexes outright (no tombstone) and updates the entriesByTable counter.
+// Callers must hold nDB.Lock() - see createOrUpdateEntry for why this can't
+// be narrowed to a per-network lock.
+func (nDB *NetworkDB) deleteEntry(nid, tname, key string) (okTable bool, okNetwork bool, oldByTable *entry) {
+	nDB.indexes[byTable], oldByTable, okTable = nDB.indexes[byTable].Delete([]byte(fmt.Sprintf("/%s/%s/%s", tname, nid, key)))
 	nDB.indexes[byNetwork], _, okNetwork = nDB.indexes[byNetwork].Delete([]byte(fmt.Sprintf("/%s/%s/%s", nid, tname, key)))
 	if okNetwork {
-		// Remove only if the delete is successful
-		n, ok := nDB.thisNodeNetworks[nid]
-		if ok {
-			n.entriesNumber.Add(-1)
+		nDB.statsMu.Lock()
+		if nDB.entriesByTable[tname] > 0 {
+			nDB.entriesByTable[tname]--
 		}
+		nDB.statsMu.Unlock()
+	}
+	return okTable, okNetwork, oldByTable
+}
+
+// deleteEntryLocal applies an already-performed deleteEntry to n's own
+// per-network bookkeeping (merkle summary, entries radix tree,
+// entriesNumber) and fires the configured EntryHook. Callers must hold
+// n.mu.Lock(), NOT nDB.Lock() - see createOrUpdateEntryAndApply.
+func (nDB *NetworkDB) deleteEntryLocal(n *thisNodeNetwork, nid, tname, key string, okNetwork bool, oldByTable *entry) {
+	if okNetwork {
+		// Remove only if the delete is successful
+		n.entriesNumber.Add(-1)
+	}
+	if n.merkle != nil && oldByTable != nil {
+		n.merkle.remove(tname, key, oldByTable)
+	}
+	if n.entries != nil {
+		n.entries, _, _ = n.entries.Delete([]byte(fmt.Sprintf("/%s/%s", tname, key)))
+	}
+	if nDB.config.EntryHook != nil && oldByTable != nil {
+		nDB.config.EntryHook(OpDelete, nid, tname, key, oldByTable)
+	}
+}
+
+// deleteEntryAndApply runs deleteEntry immediately followed by
+// deleteEntryLocal for nid's thisNodeNetwork (if joined), for callers
+// (deleteNodeNetworkEntries, deleteNodeTableEntries, LeaveNetwork) that
+// already hold nDB.Lock() for the whole operation - see
+// createOrUpdateEntryAndApply.
+func (nDB *NetworkDB) deleteEntryAndApply(nid, tname, key string) (okTable bool, okNetwork bool) {
+	okTable, okNetwork, oldByTable := nDB.deleteEntry(nid, tname, key)
+	if n, ok := nDB.thisNodeNetworks[nid]; ok {
+		n.mu.Lock()
+		nDB.deleteEntryLocal(n, nid, tname, key, okNetwork, oldByTable)
+		n.mu.Unlock()
 	}
 	return okTable, okNetwork
 }
+
+// merkleBuckets is the number of leaf buckets a network's merkle summary is
+// partitioned into. Each bucket XORs together the hashes of every entry that
+// falls into it, so adding or removing one entry is an O(1) update rather
+// than a full tree rebuild.
+const merkleBuckets = 256
+
+// merkleSummary is an incrementally maintained anti-entropy summary of one
+// network's table entries, used to detect whether two nodes' views of a
+// network have diverged without transferring the whole table.
+//
+// This is a single-level simplification of a full Merkle tree: Root hashes
+// the ordered bucket array, and DiffBuckets compares bucket-by-bucket once
+// roots disagree. A deeper tree (hashing pairs of buckets up to the root so
+// a descent can skip whole matching subtrees) would reduce the comparison
+// cost below O(merkleBuckets), but at the scale these tables run at
+// (anti-entropy is periodic, not per-write) the flat comparison is cheap
+// enough and much simpler to keep correct.
+type merkleSummary struct {
+	mu      sync.Mutex
+	buckets [merkleBuckets][sha256.Size]byte
+}
+
+func newMerkleSummary() *merkleSummary {
+	return &merkleSummary{}
+}
+
+// merkleBucketFor deterministically maps a (table, key) pair to a bucket.
+func merkleBucketFor(tname, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tname))
+	_, _ = h.Write([]byte("/"))
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % merkleBuckets)
+}
+
+// merkleEntryHash hashes the fields of an entry that matter for deciding
+// whether two nodes agree on it: its owner, its Lamport time and its value.
+// Deleted (tombstoned) entries are hashed like any other so that a pending
+// deletion itself is covered by anti-entropy, not only live values.
+func merkleEntryHash(v *entry) [sha256.Size]byte {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s/%d/%t", v.node, v.ltime, v.deleting)
+	h.Write(v.value)
+	var out [sha256.Size]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+func (m *merkleSummary) add(tname, key string, v *entry) {
+	b := merkleBucketFor(tname, key)
+	eh := merkleEntryHash(v)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range eh {
+		m.buckets[b][i] ^= eh[i]
+	}
+}
+
+func (m *merkleSummary) remove(tname, key string, v *entry) {
+	// XOR is its own inverse, so removing an entry is the same operation as
+	// adding it: the second XOR cancels the first.
+	m.add(tname, key, v)
+}
+
+// root hashes the ordered bucket array into a single summary the caller can
+// exchange with a peer cheaply; equal roots mean the networks are (very
+// likely) in sync.
+func (m *merkleSummary) root() [sha256.Size]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := sha256.New()
+	for _, b := range m.buckets {
+		h.Write(b[:])
+	}
+	var out [sha256.Size]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// diffBuckets returns the indices of buckets that differ between m and a
+// peer's bucket snapshot.
+func (m *merkleSummary) diffBuckets(peerBuckets [merkleBuckets][sha256.Size]byte) []uint16 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var mismatched []uint16
+	for i, b := range m.buckets {
+		if b != peerBuckets[i] {
+			mismatched = append(mismatched, uint16(i))
+		}
+	}
+	return mismatched
+}
+
+// snapshotBuckets returns a copy of the current bucket hashes, suitable for
+// inclusion in a SyncDigestResponse or for a peer's diffBuckets call.
+func (m *merkleSummary) snapshotBuckets() [merkleBuckets][sha256.Size]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buckets
+}
+
+// merkleLevels is the depth of the binary tree rolled up from merkleBuckets
+// leaves: level 0 is the root, merkleLevels-1 is the leaf (bucket) level.
+// merkleBuckets is a power of two, so this is exact.
+const merkleLevels = 9 // log2(256) + 1
+
+// nodeHash returns the hash of the tree node at (level, path), where level 0
+// is the root and level merkleLevels-1 addresses an individual bucket.
+// Internal nodes are computed on demand as SHA-256(left||right) rather than
+// kept incrementally up to date: with only merkleBuckets leaves the whole
+// tree is a few hundred hashes, cheap enough to recompute per digest
+// exchange (anti-entropy, not per-write) without the bookkeeping a truly
+// incremental internal-node cache would need.
+func (m *merkleSummary) nodeHash(level int, path uint32) [sha256.Size]byte {
+	if level == merkleLevels-1 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.buckets[path]
+	}
+	left := m.nodeHash(level+1, path*2)
+	right := m.nodeHash(level+1, path*2+1)
+	h := sha256.New()
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [sha256.Size]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// BulkSyncDigest asks a peer for its merkle tree node at (Level, Path) for
+// NetworkID, as a step in descending to the buckets that actually differ
+// instead of shipping every entry on every reconnect.
+type BulkSyncDigest struct {
+	NetworkID string
+	Level     int
+	Path      uint32
+	Hash      [sha256.Size]byte
+}
+
+// BulkSyncDigestResponse answers a BulkSyncDigest. When Match is false and
+// Level is not the leaf level, ChildHashes carries the two child hashes so
+// the requester can recurse into whichever differs. At the leaf level, a
+// non-match instead means the whole bucket identified by Path needs a
+// real entry-level diff, which BucketKeys below provides.
+type BulkSyncDigestResponse struct {
+	NetworkID   string
+	Level       int
+	Path        uint32
+	Match       bool
+	ChildHashes [2][sha256.Size]byte
+}
+
+// DigestAt answers a BulkSyncDigest for nid, recomputing the local tree node
+// at (req.Level, req.Path) and comparing it to req.Hash.
+func (nDB *NetworkDB) DigestAt(req BulkSyncDigest) (BulkSyncDigestResponse, error) {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[req.NetworkID]
+	nDB.RUnlock()
+	if !ok {
+		return BulkSyncDigestResponse{}, fmt.Errorf("networkdb: network %s is not joined", req.NetworkID)
+	}
+
+	// n.merkle is lazily initialized here and otherwise only ever mutated
+	// while holding n.mu, not nDB.Lock() - see createOrUpdateEntryLocal,
+	// deleteEntryLocal and rebuildMerkleLocked - so the read-check-assign
+	// here and the subsequent nodeHash walk must hold n.mu too, or a
+	// concurrent CreateEntry/UpdateEntry/DeleteEntry on this same network
+	// can race on the n.merkle pointer.
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.merkle == nil {
+		n.merkle = newMerkleSummary()
+	}
+
+	resp := BulkSyncDigestResponse{NetworkID: req.NetworkID, Level: req.Level, Path: req.Path}
+	resp.Match = n.merkle.nodeHash(req.Level, req.Path) == req.Hash
+	if !resp.Match && req.Level < merkleLevels-1 {
+		resp.ChildHashes[0] = n.merkle.nodeHash(req.Level+1, req.Path*2)
+		resp.ChildHashes[1] = n.merkle.nodeHash(req.Level+1, req.Path*2+1)
+	}
+	return resp, nil
+}
+
+// BucketKeys returns the (table, key) pairs of every entry this node
+// currently has in merkle bucket for nid, so a descent that bottoms out at
+// a mismatched leaf can resolve it to the handful of actual differing
+// entries (exchanged as today via CreateEntry/UpdateEntry/DeleteEntry's
+// TableEventMessage) instead of shipping the whole network.
+func (nDB *NetworkDB) BucketKeys(nid string, bucket uint32) []KV {
+	nDB.RLock()
+	defer nDB.RUnlock()
+	var out []KV
+	nDB.indexes[byNetwork].Root().WalkPrefix([]byte("/"+nid), func(path []byte, v *entry) bool {
+		params := strings.Split(string(path[1:]), "/")
+		tname, key := params[1], params[2]
+		if uint32(merkleBucketFor(tname, key)) == bucket {
+			out = append(out, KV{Table: tname, NetworkID: nid, Key: key, Value: v.value})
+		}
+		return false
+	})
+	return out
+}
+
+// KV is one table entry, returned by BucketKeys and Scan.
+type KV struct {
+	Table     string
+	NetworkID string
+	Key       string
+	Value     []byte
+}
+
+// rebuildMerkle recomputes nid's merkle summary from scratch by walking
+// indexes[byNetwork], discarding whatever was built incrementally. Called
+// after events that make incremental XOR maintenance untrustworthy as the
+// sole source of truth: a restored snapshot and WAL replay (restoreFromDisk)
+// and LeaveNetwork's bulk tombstone rewrite (via rebuildMerkleLocked), so
+// the tree reflects exactly what's in the radix tree rather than whatever
+// sequence of add/remove calls produced it.
+//
+// The walk of indexes[byNetwork] runs under nDB.Lock(), and the rebuilt
+// merkle summary and entries tree are installed together under n.mu, so a
+// reader holding n.mu (DigestAt, Scan) never sees a summary from one
+// generation of the tree paired with an entries tree from another.
+func (nDB *NetworkDB) rebuildMerkle(nid string) {
+	nDB.Lock()
+	defer nDB.Unlock()
+	nDB.rebuildMerkleLocked(nid)
+}
+
+// rebuildMerkleLocked is rebuildMerkle for callers that already hold
+// nDB.Lock(), such as LeaveNetwork.
+func (nDB *NetworkDB) rebuildMerkleLocked(nid string) {
+	n, ok := nDB.thisNodeNetworks[nid]
+	if !ok {
+		return
+	}
+	fresh := newMerkleSummary()
+	freshEntries := iradix.New[*entry]()
+	nDB.indexes[byNetwork].Root().WalkPrefix([]byte("/"+nid), func(path []byte, v *entry) bool {
+		params := strings.Split(string(path[1:]), "/")
+		tname, key := params[1], params[2]
+		fresh.add(tname, key, v)
+		freshEntries, _, _ = freshEntries.Insert([]byte(fmt.Sprintf("/%s/%s", tname, key)), v)
+		return false
+	})
+	n.mu.Lock()
+	n.merkle = fresh
+	n.entries = freshEntries
+	n.mu.Unlock()
+}
+
+// SyncDigestRequest is sent to a peer to ask whether its view of a network
+// matches ours, before falling back to a full bulkSync of that network.
+// Buckets carries the requester's full per-bucket summary so the responder
+// can localize a mismatch to specific buckets in a single round trip,
+// rather than TreeRoot alone, which can only say "something differs".
+type SyncDigestRequest struct {
+	NetworkID string
+	TreeRoot  [sha256.Size]byte
+	Buckets   [merkleBuckets][sha256.Size]byte
+}
+
+// SyncDigestResponse answers a SyncDigestRequest. MismatchedBuckets is only
+// populated when the requester's root didn't match ours; it's empty when
+// the two networks are already in sync.
+type SyncDigestResponse struct {
+	NetworkID         string
+	TreeRoot          [sha256.Size]byte
+	MismatchedBuckets []uint16
+}
+
+// MerkleRoot returns the current anti-entropy summary root for nid, for use
+// in a SyncDigestRequest. It returns an error if this node hasn't joined
+// nid.
+//
+// Wiring SyncDigestRequest/SyncDigestResponse onto the wire (as new gossip
+// message types alongside the existing TCP push/pull used by bulkSync) and
+// triggering it periodically per network is the job of the gossip delegate,
+// which isn't part of this file; bulkSync remains the correct fallback for
+// a node whose peer doesn't understand these message types, or after this
+// method's caller finds a non-empty MismatchedBuckets response.
+func (nDB *NetworkDB) MerkleRoot(nid string) ([sha256.Size]byte, error) {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	nDB.RUnlock()
+	if !ok {
+		return [sha256.Size]byte{}, fmt.Errorf("networkdb: network %s is not joined", nid)
+	}
+	n.mu.RLock()
+	m := n.merkle
+	n.mu.RUnlock()
+	if m == nil {
+		return [sha256.Size]byte{}, nil
+	}
+	return m.root(), nil
+}
+
+// MerkleBuckets returns a snapshot of the per-bucket summary for nid, for
+// populating SyncDigestRequest.Buckets.
+func (nDB *NetworkDB) MerkleBuckets(nid string) ([merkleBuckets][sha256.Size]byte, error) {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[nid]
+	nDB.RUnlock()
+	if !ok {
+		return [merkleBuckets][sha256.Size]byte{}, fmt.Errorf("networkdb: network %s is not joined", nid)
+	}
+	n.mu.RLock()
+	m := n.merkle
+	n.mu.RUnlock()
+	if m == nil {
+		return [merkleBuckets][sha256.Size]byte{}, nil
+	}
+	return m.snapshotBuckets(), nil
+}
+
+// CompareDigest answers a SyncDigestRequest for nid: resp.MismatchedBuckets
+// is empty when req.TreeRoot already matches our summary, and otherwise
+// lists the buckets the caller should bulk-sync instead of the whole
+// network.
+func (nDB *NetworkDB) CompareDigest(req SyncDigestRequest) (SyncDigestResponse, error) {
+	nDB.RLock()
+	n, ok := nDB.thisNodeNetworks[req.NetworkID]
+	nDB.RUnlock()
+	if !ok {
+		return SyncDigestResponse{}, fmt.Errorf("networkdb: network %s is not joined", req.NetworkID)
+	}
+
+	// See the matching comment in DigestAt: n.merkle is only ever mutated
+	// under n.mu, so the read-check-assign here must hold it too, not
+	// nDB's top-level lock.
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.merkle == nil {
+		n.merkle = newMerkleSummary()
+	}
+
+	resp := SyncDigestResponse{NetworkID: req.NetworkID, TreeRoot: n.merkle.root()}
+	if resp.TreeRoot != req.TreeRoot {
+		resp.MismatchedBuckets = n.merkle.diffBuckets(req.Buckets)
+	}
+	return resp, nil
+}
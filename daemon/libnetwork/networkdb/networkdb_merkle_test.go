@@ -0,0 +1,151 @@
+package networkdb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// newTestNetworkDBJoined builds a NetworkDB with a single joined network and
+// no merkle summary yet, so callers can exercise the lazy-init path in
+// DigestAt/CompareDigest.
+func newTestNetworkDBJoined(nid string) *NetworkDB {
+	nDB := newNetworkDB(&Config{NodeID: "testnode"})
+	nDB.thisNodeNetworks[nid] = &thisNodeNetwork{}
+	return nDB
+}
+
+// TestDigestAtAndCompareDigestConcurrentLazyInit is a regression test for a
+// race where DigestAt/CompareDigest read-checked-and-assigned n.merkle under
+// only nDB.RLock(), while every mutator of n.merkle (createOrUpdateEntryLocal,
+// deleteEntryLocal, JoinNetwork, rebuildMerkleLocked) holds n.mu. Run with
+// `go test -race` to catch a reintroduction of the RLock() version.
+func TestDigestAtAndCompareDigestConcurrentLazyInit(t *testing.T) {
+	const nid = "nid1"
+	nDB := newTestNetworkDBJoined(nid)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := nDB.DigestAt(BulkSyncDigest{NetworkID: nid}); err != nil {
+				t.Errorf("DigestAt: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := nDB.CompareDigest(SyncDigestRequest{NetworkID: nid}); err != nil {
+				t.Errorf("CompareDigest: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestMerkleReadersRaceAgainstRealMutators is a regression test for a race
+// between n.merkle's readers (DigestAt, CompareDigest, MerkleRoot,
+// MerkleBuckets) and its actual mutators (createOrUpdateEntryLocal,
+// deleteEntryLocal, called from CreateEntry/UpdateEntry/DeleteEntry's
+// per-network phase, and rebuildMerkleLocked). The previous version of this
+// test only raced DigestAt/CompareDigest against each other, which never
+// would have caught the real bug: the four readers took nDB.Lock()/RLock(),
+// while the mutators take n.mu, two independent locks guarding the same
+// n.merkle pointer. Run with `go test -race` to catch a reintroduction of
+// that mismatch.
+func TestMerkleReadersRaceAgainstRealMutators(t *testing.T) {
+	const (
+		nid   = "nid1"
+		tname = "tbl"
+	)
+	nDB := newTestNetworkDBJoined(nid)
+	n := nDB.thisNodeNetworks[nid]
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	readers := []func(){
+		func() {
+			if _, err := nDB.DigestAt(BulkSyncDigest{NetworkID: nid}); err != nil {
+				t.Errorf("DigestAt: %v", err)
+			}
+		},
+		func() {
+			if _, err := nDB.CompareDigest(SyncDigestRequest{NetworkID: nid}); err != nil {
+				t.Errorf("CompareDigest: %v", err)
+			}
+		},
+		func() {
+			if _, err := nDB.MerkleRoot(nid); err != nil {
+				t.Errorf("MerkleRoot: %v", err)
+			}
+		},
+		func() {
+			if _, err := nDB.MerkleBuckets(nid); err != nil {
+				t.Errorf("MerkleBuckets: %v", err)
+			}
+		},
+	}
+	for _, read := range readers {
+		wg.Add(1)
+		go func(read func()) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					read()
+				}
+			}
+		}(read)
+	}
+
+	// Mutators: the same split-lock path CreateEntry/UpdateEntry/DeleteEntry
+	// use for their per-network phase (createOrUpdateEntryLocal mutates
+	// n.merkle under n.mu).
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		v := &entry{value: []byte("v")}
+
+		nDB.Lock()
+		_, okNetwork, oldByTable := nDB.createOrUpdateEntry(nid, tname, key, v)
+		nDB.Unlock()
+
+		n.mu.Lock()
+		nDB.createOrUpdateEntryLocal(n, nid, tname, key, v, oldByTable, !okNetwork)
+		n.mu.Unlock()
+	}
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		nDB.Lock()
+		_, okNetwork, oldByTable := nDB.deleteEntry(nid, tname, key)
+		nDB.Unlock()
+
+		n.mu.Lock()
+		nDB.deleteEntryLocal(n, nid, tname, key, okNetwork, oldByTable)
+		n.mu.Unlock()
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+// TestDigestAtMatchesRootAfterLazyInit checks that DigestAt's root-level
+// hash agrees with a fresh (all-zero) merkle summary once it has been
+// lazily initialized, i.e. that the lazy-init path actually installs a
+// usable *merkleSummary rather than leaving callers to observe a nil one.
+func TestDigestAtMatchesRootAfterLazyInit(t *testing.T) {
+	const nid = "nid1"
+	nDB := newTestNetworkDBJoined(nid)
+
+	want := newMerkleSummary().nodeHash(0, 0)
+	resp, err := nDB.DigestAt(BulkSyncDigest{NetworkID: nid, Level: 0, Path: 0, Hash: want})
+	if err != nil {
+		t.Fatalf("DigestAt: %v", err)
+	}
+	if !resp.Match {
+		t.Errorf("expected a freshly lazy-initialized merkle tree to match an empty summary's root hash")
+	}
+}
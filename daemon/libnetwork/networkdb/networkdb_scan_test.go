@@ -0,0 +1,91 @@
+package networkdb
+
+import (
+	"fmt"
+	"testing"
+
+	iradix "github.com/hashicorp/go-immutable-radix/v2"
+)
+
+// newTestNetworkDBWithEntries builds a NetworkDB with a single joined
+// network whose table already holds n entries, without going through
+// JoinNetwork/CreateEntry (both require cluster gossip plumbing this unit
+// test doesn't set up). Keys are zero-padded so radix-tree iteration order
+// matches numeric order, which makes the expected page boundaries
+// predictable.
+func newTestNetworkDBWithEntries(nid, tname string, n int) *NetworkDB {
+	nDB := newNetworkDB(&Config{NodeID: "testnode"})
+
+	tree := iradix.New[*entry]()
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("/%s/key%04d", tname, i)
+		txn := tree.Txn()
+		txn.Insert([]byte(key), &entry{value: []byte(fmt.Sprintf("val%04d", i))})
+		tree = txn.Commit()
+	}
+
+	nDB.thisNodeNetworks[nid] = &thisNodeNetwork{entries: tree}
+	return nDB
+}
+
+// TestScanPaginatesWithoutDroppingEntries is a regression test for a bug
+// where Scan used the first entry past the page (keys[limit]) as the next
+// cursor instead of the last entry actually returned (keys[limit-1]).
+// Since Scan excludes any key <= cursor, using keys[limit] as the cursor
+// silently dropped that in-between entry from every subsequent page.
+func TestScanPaginatesWithoutDroppingEntries(t *testing.T) {
+	const (
+		nid   = "nid1"
+		tname = "tbl"
+		total = 10
+		limit = 3
+	)
+	nDB := newTestNetworkDBWithEntries(nid, tname, total)
+
+	var got []KV
+	var cursor []byte
+	for {
+		entries, next, err := nDB.Scan(nid, tname, "", cursor, limit)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		got = append(got, entries...)
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+
+	if len(got) != total {
+		t.Fatalf("Scan across pages returned %d entries, want %d: %+v", len(got), total, got)
+	}
+	for i, kv := range got {
+		want := fmt.Sprintf("key%04d", i)
+		if kv.Key != want {
+			t.Errorf("entry %d: key = %q, want %q", i, kv.Key, want)
+		}
+	}
+}
+
+// TestScanSinglePageUnderLimit verifies Scan returns a nil cursor (no more
+// pages) when the result set is smaller than the requested limit.
+func TestScanSinglePageUnderLimit(t *testing.T) {
+	const (
+		nid   = "nid1"
+		tname = "tbl"
+		total = 2
+		limit = 5
+	)
+	nDB := newTestNetworkDBWithEntries(nid, tname, total)
+
+	entries, next, err := nDB.Scan(nid, tname, "", nil, limit)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if next != nil {
+		t.Errorf("expected nil cursor for an under-limit result, got %q", next)
+	}
+	if len(entries) != total {
+		t.Fatalf("got %d entries, want %d", len(entries), total)
+	}
+}
@@ -2,9 +2,14 @@ package daemon
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -57,28 +62,40 @@ func (daemon *Daemon) NetworkController() *libnetwork.Controller {
 // 3. Partial ID
 // as long as there is no ambiguity
 func (daemon *Daemon) FindNetwork(term string) (*libnetwork.Network, error) {
-	var listByFullName, listByPartialID []*libnetwork.Network
 	for _, nw := range daemon.getAllNetworks() {
-		nwID := nw.ID()
-		if nwID == term {
+		if nw.ID() == term {
 			return nw, nil
 		}
-		if strings.HasPrefix(nw.ID(), term) {
-			listByPartialID = append(listByPartialID, nw)
-		}
-		if nw.Name() == term {
-			listByFullName = append(listByFullName, nw)
-		}
 	}
-	switch {
-	case len(listByFullName) == 1:
-		return listByFullName[0], nil
-	case len(listByFullName) > 1:
-		return nil, errdefs.InvalidParameter(fmt.Errorf("network %s is ambiguous (%d matches found on name)", term, len(listByFullName)))
-	case len(listByPartialID) == 1:
-		return listByPartialID[0], nil
-	case len(listByPartialID) > 1:
-		return nil, errdefs.InvalidParameter(fmt.Errorf("network %s is ambiguous (%d matches found based on ID prefix)", term, len(listByPartialID)))
+
+	// Name and ID-prefix resolution are both expressed as SelectNetworks
+	// predicates, so richer callers (for example the API router) can reuse
+	// the exact same matching rules via a single Selector instead of
+	// re-implementing them.
+	byName, err := daemon.SelectNetworks(context.TODO(), networktypes.Selector{NamePattern: term})
+	if err != nil {
+		return nil, err
+	}
+	switch len(byName) {
+	case 1:
+		return byName[0], nil
+	case 0:
+		// fall through to ID-prefix resolution
+	default:
+		return nil, errdefs.InvalidParameter(fmt.Errorf("network %s is ambiguous (%d matches found on name)", term, len(byName)))
+	}
+
+	byPrefix, err := daemon.SelectNetworks(context.TODO(), networktypes.Selector{IDPrefix: term})
+	if err != nil {
+		return nil, err
+	}
+	switch len(byPrefix) {
+	case 1:
+		return byPrefix[0], nil
+	case 0:
+		// fall through to the not-found case below
+	default:
+		return nil, errdefs.InvalidParameter(fmt.Errorf("network %s is ambiguous (%d matches found based on ID prefix)", term, len(byPrefix)))
 	}
 
 	// Be very careful to change the error type here, the
@@ -87,6 +104,147 @@ func (daemon *Daemon) FindNetwork(term string) (*libnetwork.Network, error) {
 	return nil, errdefs.NotFound(libnetwork.ErrNoSuchNetwork(term))
 }
 
+// SelectNetworks returns the networks matching every predicate set on sel.
+// Unlike FindNetwork, which resolves exactly one network by identity,
+// SelectNetworks is meant for richer list/inspect queries (for example,
+// "the overlay network with label env=prod") without every caller walking
+// getAllNetworks() and re-filtering by hand.
+func (daemon *Daemon) SelectNetworks(ctx context.Context, sel networktypes.Selector) ([]*libnetwork.Network, error) {
+	labels, err := parseLabelSelector(sel.LabelSelector)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+
+	var matches []*libnetwork.Network
+	for _, nw := range daemon.getAllNetworks() {
+		if sel.IDPrefix != "" && !strings.HasPrefix(nw.ID(), sel.IDPrefix) {
+			continue
+		}
+		if sel.NamePattern != "" {
+			ok, err := filepath.Match(sel.NamePattern, nw.Name())
+			if err != nil {
+				return nil, errdefs.InvalidParameter(fmt.Errorf("invalid name pattern %q: %w", sel.NamePattern, err))
+			}
+			if !ok {
+				continue
+			}
+		}
+		if sel.Driver != "" && nw.Type() != sel.Driver {
+			continue
+		}
+		if sel.Scope != "" && nw.Scope() != sel.Scope {
+			continue
+		}
+		if !labels.matches(nw.Labels()) {
+			continue
+		}
+		if sel.AttachedContainer != "" && !networkHasAttachedContainer(nw, sel.AttachedContainer) {
+			continue
+		}
+		matches = append(matches, nw)
+	}
+
+	return matches, nil
+}
+
+// networkHasAttachedContainer reports whether containerID has an endpoint on nw.
+func networkHasAttachedContainer(nw *libnetwork.Network, containerID string) bool {
+	for _, ep := range nw.Endpoints() {
+		epInfo := ep.Info()
+		if epInfo == nil {
+			continue
+		}
+		if sb := epInfo.Sandbox(); sb != nil && sb.ContainerID() == containerID {
+			return true
+		}
+	}
+	return false
+}
+
+// labelSelector is a parsed k8s-style label selector: a conjunction of
+// equality (key=value), inequality (key!=value), and set-membership
+// (key in (a,b)) requirements.
+type labelSelector struct {
+	equals    map[string]string
+	notEquals map[string]string
+	in        map[string][]string
+}
+
+// parseLabelSelector parses a comma-separated k8s-style label selector
+// expression, for example "env=prod,tier!=edge,az in (us-east-1,us-west-2)".
+func parseLabelSelector(raw string) (labelSelector, error) {
+	sel := labelSelector{equals: map[string]string{}, notEquals: map[string]string{}, in: map[string][]string{}}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return sel, nil
+	}
+
+	for _, term := range splitLabelSelectorTerms(raw) {
+		term = strings.TrimSpace(term)
+		switch {
+		case strings.Contains(term, "!="):
+			key, value, _ := strings.Cut(term, "!=")
+			sel.notEquals[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		case strings.Contains(term, " in ("):
+			key, rest, _ := strings.Cut(term, " in (")
+			var values []string
+			for _, v := range strings.Split(strings.TrimSuffix(strings.TrimSpace(rest), ")"), ",") {
+				values = append(values, strings.TrimSpace(v))
+			}
+			sel.in[strings.TrimSpace(key)] = values
+		case strings.Contains(term, "="):
+			key, value, _ := strings.Cut(term, "=")
+			sel.equals[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		default:
+			return labelSelector{}, fmt.Errorf("invalid label selector term %q", term)
+		}
+	}
+
+	return sel, nil
+}
+
+// splitLabelSelectorTerms splits a label selector on top-level commas,
+// ignoring commas nested inside an "in (...)" value list.
+func splitLabelSelectorTerms(raw string) []string {
+	var terms []string
+	depth := 0
+	start := 0
+	for i, r := range raw {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, raw[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, raw[start:])
+}
+
+// matches reports whether labels satisfies every requirement in s.
+func (s labelSelector) matches(labels map[string]string) bool {
+	for k, v := range s.equals {
+		if labels[k] != v {
+			return false
+		}
+	}
+	for k, v := range s.notEquals {
+		if labels[k] == v {
+			return false
+		}
+	}
+	for k, values := range s.in {
+		if !slices.Contains(values, labels[k]) {
+			return false
+		}
+	}
+	return true
+}
+
 // GetNetworkByID function returns a network whose ID matches the given ID.
 // It fails with an error if no matching network is found.
 func (daemon *Daemon) GetNetworkByID(id string) (*libnetwork.Network, error) {
@@ -145,51 +303,75 @@ type ingressJob struct {
 }
 
 var (
-	ingressWorkerOnce  sync.Once
-	ingressJobsChannel chan *ingressJob
-	ingressID          string
+	// ingressMu protects ingressWorkers and ingressIDs below.
+	ingressMu sync.Mutex
+	// ingressWorkers holds one job queue per ingress group, so that
+	// setup/teardown of one ingress network never blocks another.
+	ingressWorkers = make(map[string]chan *ingressJob)
+	// ingressIDs tracks the current (stale, once superseded) network ID for
+	// each ingress group.
+	ingressIDs = make(map[string]string)
 )
 
-func (daemon *Daemon) startIngressWorker() {
-	ingressJobsChannel = make(chan *ingressJob, 100)
-	go func() {
-		for r := range ingressJobsChannel {
-			if r.create != nil {
-				daemon.setupIngress(&daemon.config().Config, r.create, r.ip, ingressID)
-				ingressID = r.create.ID
-			} else {
-				daemon.releaseIngress(ingressID)
-				ingressID = ""
-			}
-			close(r.jobDone)
+// runIngressWorker drains jobs queued for a single ingress group, in order.
+// Jobs for different ingress groups run on independent goroutines and make
+// progress concurrently.
+func (daemon *Daemon) runIngressWorker(ingressGroup string, jobs chan *ingressJob) {
+	for r := range jobs {
+		ingressMu.Lock()
+		staleID := ingressIDs[ingressGroup]
+		ingressMu.Unlock()
+
+		if r.create != nil {
+			daemon.setupIngress(&daemon.config().Config, r.create, r.ip, staleID)
+			ingressMu.Lock()
+			ingressIDs[ingressGroup] = r.create.ID
+			ingressMu.Unlock()
+		} else {
+			daemon.releaseIngress(staleID)
+			ingressMu.Lock()
+			delete(ingressIDs, ingressGroup)
+			ingressMu.Unlock()
 		}
-	}()
+		close(r.jobDone)
+	}
 }
 
-// enqueueIngressJob adds a ingress add/rm request to the worker queue.
-// It guarantees the worker is started.
-func (daemon *Daemon) enqueueIngressJob(job *ingressJob) {
-	ingressWorkerOnce.Do(daemon.startIngressWorker)
-	ingressJobsChannel <- job
+// enqueueIngressJob adds an ingress add/rm request to the worker queue for
+// the given ingress group. It guarantees a worker for that group is started.
+func (daemon *Daemon) enqueueIngressJob(ingressGroup string, job *ingressJob) {
+	ingressMu.Lock()
+	jobs, ok := ingressWorkers[ingressGroup]
+	if !ok {
+		jobs = make(chan *ingressJob, 100)
+		ingressWorkers[ingressGroup] = jobs
+		go daemon.runIngressWorker(ingressGroup, jobs)
+	}
+	ingressMu.Unlock()
+
+	jobs <- job
 }
 
-// SetupIngress setups ingress networking.
+// SetupIngress sets up ingress networking for the given ingress group (a
+// swarm-supplied identifier, for example scoping a data-plane VLAN). Swarm
+// deployments with multiple ingress groups are programmed concurrently and
+// independently; only jobs within the same group are serialized.
 // The function returns a channel which will signal the caller when the programming is completed.
-func (daemon *Daemon) SetupIngress(create clustertypes.NetworkCreateRequest, nodeIP string) (<-chan struct{}, error) {
+func (daemon *Daemon) SetupIngress(ingressGroup string, create clustertypes.NetworkCreateRequest, nodeIP string) (<-chan struct{}, error) {
 	ip, _, err := net.ParseCIDR(nodeIP)
 	if err != nil {
 		return nil, err
 	}
 	done := make(chan struct{})
-	daemon.enqueueIngressJob(&ingressJob{&create, ip, done})
+	daemon.enqueueIngressJob(ingressGroup, &ingressJob{&create, ip, done})
 	return done, nil
 }
 
-// ReleaseIngress releases the ingress networking.
+// ReleaseIngress releases the ingress networking for the given ingress group.
 // The function returns a channel which will signal the caller when the programming is completed.
-func (daemon *Daemon) ReleaseIngress() (<-chan struct{}, error) {
+func (daemon *Daemon) ReleaseIngress(ingressGroup string) (<-chan struct{}, error) {
 	done := make(chan struct{})
-	daemon.enqueueIngressJob(&ingressJob{nil, nil, done})
+	daemon.enqueueIngressJob(ingressGroup, &ingressJob{nil, nil, done})
 	return done, nil
 }
 
@@ -283,11 +465,132 @@ func (daemon *Daemon) CreateNetwork(ctx context.Context, create networktypes.Cre
 	return daemon.createNetwork(ctx, &daemon.config().Config, create, "", false)
 }
 
+// UpdateNetwork updates mutable attributes of an existing network: labels,
+// driver options the driver marks as mutable, attachability, and additional
+// IPAM auxiliary addresses. Subnets, scope, and ingress are immutable;
+// changing them requires deleting and recreating the network.
+func (daemon *Daemon) UpdateNetwork(ctx context.Context, id string, update networktypes.UpdateRequest) error {
+	n, err := daemon.GetNetworkByID(id)
+	if err != nil {
+		return err
+	}
+
+	if network.IsPredefined(n.Name()) || n.Ingress() {
+		return errdefs.Forbidden(fmt.Errorf("network %s does not support attribute updates", n.Name()))
+	}
+
+	updateOpts, err := daemon.buildNetworkUpdateOptions(n, update)
+	if err != nil {
+		return err
+	}
+	if len(updateOpts) == 0 {
+		return nil
+	}
+
+	if err := n.Update(updateOpts...); err != nil {
+		return err
+	}
+
+	daemon.LogNetworkEvent(n, events.ActionUpdate)
+	return nil
+}
+
+// buildNetworkUpdateOptions validates the requested changes against the
+// network's current configuration and the driver's supported mutable
+// options, translating them into [libnetwork.NetworkOptionUpdate] values.
+// It rejects attempts to change subnets, scope, or ingress.
+func (daemon *Daemon) buildNetworkUpdateOptions(n *libnetwork.Network, update networktypes.UpdateRequest) ([]libnetwork.NetworkOptionUpdate, error) {
+	var updateOptions []libnetwork.NetworkOptionUpdate
+
+	if update.Labels != nil {
+		updateOptions = append(updateOptions, libnetwork.NetworkOptionUpdateLabels(update.Labels))
+	}
+
+	if len(update.DriverOpts) > 0 {
+		mutableOpts, err := n.ValidateMutableDriverOpts(update.DriverOpts)
+		if err != nil {
+			return nil, errdefs.InvalidParameter(fmt.Errorf("driver %s does not support updating the requested options: %w", n.Type(), err))
+		}
+		updateOptions = append(updateOptions, libnetwork.NetworkOptionUpdateDriverOpts(mutableOpts))
+	}
+
+	if update.Attachable != nil {
+		updateOptions = append(updateOptions, libnetwork.NetworkOptionUpdateAttachable(*update.Attachable))
+	}
+
+	if update.IPAM != nil {
+		for _, cfg := range update.IPAM.Config {
+			for auxKey, auxAddr := range cfg.AuxAddress {
+				updateOptions = append(updateOptions, libnetwork.NetworkOptionUpdateAddAuxAddress(cfg.Subnet, auxKey, auxAddr))
+			}
+		}
+	}
+
+	return updateOptions, nil
+}
+
+// resolveNetworkConflict decides how createNetwork should react to an
+// existing network already named create.Name, according to policy. A
+// non-nil resp is the final response createNetwork should return as-is
+// (no network is created); a non-nil err is likewise final. Otherwise
+// creation should proceed, carrying the returned warning.
+func resolveNetworkConflict(policy networktypes.ConflictPolicy, name, existingID string) (resp *networktypes.CreateResponse, warning string, err error) {
+	switch policy {
+	case networktypes.ConflictPolicyReject:
+		return nil, "", errdefs.Conflict(libnetwork.NetworkNameError(name))
+	case networktypes.ConflictPolicyReturnExisting:
+		return &networktypes.CreateResponse{
+			ID:      existingID,
+			Warning: fmt.Sprintf("network with name %s already exists; returning existing network id %s", name, existingID),
+		}, "", nil
+	default:
+		// ConflictPolicyWarn, and the zero value (for callers that
+		// predate this field), preserve today's behavior: creation
+		// proceeds, and libnetwork will surface its own "network with
+		// name already exists" error unless the driver allows
+		// same-name networks (for example, differing scopes).
+		return nil, fmt.Sprintf("network with name %s already exists", name), nil
+	}
+}
+
+// checkNetworkConflict looks up an existing network named create.Name via
+// lookup and, if one is found, resolves the conflict per create.ConflictPolicy.
+// A non-nil resp is the final response createNetwork should return as-is
+// (no network is created); a non-nil err is likewise final. Otherwise
+// creation should proceed, carrying the returned warning. It is pulled out
+// of createNetwork, rather than inlined, so the check-then-act composition
+// itself - not just resolveNetworkConflict in isolation - can be exercised
+// by tests without a real netController.
+func checkNetworkConflict(lookup func(name string) (existingID string, found bool), create networktypes.CreateRequest) (resp *networktypes.CreateResponse, warning string, err error) {
+	existingID, found := lookup(create.Name)
+	if !found {
+		return nil, "", nil
+	}
+	return resolveNetworkConflict(create.ConflictPolicy, create.Name, existingID)
+}
+
 func (daemon *Daemon) createNetwork(ctx context.Context, cfg *config.Config, create networktypes.CreateRequest, id string, agent bool) (*networktypes.CreateResponse, error) {
 	if network.IsPredefined(create.Name) {
 		return nil, PredefinedNetworkError(create.Name)
 	}
 
+	// Resolve name conflicts with an existing network according to the
+	// caller's ConflictPolicy before doing any of the (expensive, and hard
+	// to unwind) work of actually creating the network.
+	resp, warning, err := checkNetworkConflict(func(name string) (string, bool) {
+		existing, err := daemon.GetNetworkByName(name)
+		if err != nil || existing == nil {
+			return "", false
+		}
+		return existing.ID(), true
+	}, create)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		return resp, nil
+	}
+
 	c := daemon.netController
 	driver := create.Driver
 	if driver == "" {
@@ -413,10 +716,61 @@ func (daemon *Daemon) createNetwork(ctx context.Context, cfg *config.Config, cre
 	daemon.pluginRefCount(driver, driverapi.NetworkPluginEndpointType, plugingetter.Acquire)
 	if create.IPAM != nil {
 		daemon.pluginRefCount(create.IPAM.Driver, ipamapi.PluginEndpointType, plugingetter.Acquire)
+		if create.IPAM.Driver == ipamDriverPrefixDelegation {
+			daemon.watchPrefixDelegation(n)
+		}
 	}
 	daemon.LogNetworkEvent(n, events.ActionCreate)
 
-	return &networktypes.CreateResponse{ID: n.ID()}, nil
+	return &networktypes.CreateResponse{ID: n.ID(), Warning: warning}, nil
+}
+
+// ipamDriverPrefixDelegation is the IPAM driver name for networks whose IPv6
+// subnet is obtained from an upstream router via DHCPv6 prefix delegation or
+// SLAAC, rather than specified statically by the operator.
+const ipamDriverPrefixDelegation = "pd"
+
+// watchPrefixDelegation starts a goroutine that runs for the lifetime of a
+// network configured with the "pd" IPAM driver and returns without waiting
+// for it. The goroutine ranges over the lease-renewal channel exposed by
+// libnetwork's pd IPAM driver and, each time the delegated prefix changes,
+// re-pools the network and gracefully re-addresses already-attached
+// endpoints rather than leaving them on a withdrawn prefix.
+func (daemon *Daemon) watchPrefixDelegation(n *libnetwork.Network) {
+	leases, err := n.WatchIpamPrefix()
+	if err != nil {
+		log.G(context.TODO()).WithError(err).WithField("network", n.ID()).
+			Warn("prefix delegation renewal is not supported by this network's IPAM driver")
+		return
+	}
+
+	go func() {
+		for prefix := range leases {
+			if err := n.RepoolIPAM(prefix); err != nil {
+				log.G(context.TODO()).WithError(err).WithField("network", n.ID()).
+					Error("failed to re-pool network after prefix delegation renewal")
+				continue
+			}
+			daemon.readdressIPv6Endpoints(n, prefix)
+		}
+	}()
+}
+
+// readdressIPv6Endpoints deprecates each attached endpoint's old IPv6
+// address (per RFC 4862) in favor of a new one drawn from prefix, and
+// re-emits a network-update event so subscribers (including NetworkSettings
+// consumers) observe the renumbering.
+func (daemon *Daemon) readdressIPv6Endpoints(n *libnetwork.Network, prefix *net.IPNet) {
+	for _, ep := range n.Endpoints() {
+		if err := ep.ReaddressIPv6(prefix); err != nil {
+			log.G(context.TODO()).WithError(err).WithFields(log.Fields{
+				"network":  n.ID(),
+				"endpoint": ep.ID(),
+			}).Error("failed to re-address endpoint after prefix delegation renewal")
+			continue
+		}
+		daemon.LogNetworkEvent(n, events.ActionUpdate)
+	}
 }
 
 func (daemon *Daemon) pluginRefCount(driver, capability string, mode int) {
@@ -714,6 +1068,11 @@ func buildIPAMResources(nw *libnetwork.Network) networktypes.IPAM {
 
 	ipamDriver, ipamOptions, ipv4Conf, ipv6Conf := nw.IpamConfig()
 
+	// Subnets obtained from an upstream router via DHCPv6 prefix delegation
+	// or SLAAC are not durable operator input: they can be renumbered at any
+	// time by watchPrefixDelegation, so mark them as ephemeral for inspect.
+	ephemeral := ipamDriver == ipamDriverPrefixDelegation
+
 	hasIPv4Config := false
 	for _, cfg := range ipv4Conf {
 		if cfg.PreferredPool == "" {
@@ -725,6 +1084,7 @@ func buildIPAMResources(nw *libnetwork.Network) networktypes.IPAM {
 			IPRange:    cfg.SubPool,
 			Gateway:    cfg.Gateway,
 			AuxAddress: cfg.AuxAddresses,
+			Ephemeral:  ephemeral,
 		})
 	}
 
@@ -739,6 +1099,7 @@ func buildIPAMResources(nw *libnetwork.Network) networktypes.IPAM {
 			IPRange:    cfg.SubPool,
 			Gateway:    cfg.Gateway,
 			AuxAddress: cfg.AuxAddresses,
+			Ephemeral:  ephemeral,
 		})
 	}
 
@@ -830,6 +1191,178 @@ func (daemon *Daemon) clearAttachableNetworks() {
 	}
 }
 
+// endpointDNSDriverOpt is the per-endpoint driver option that requests
+// network-scoped DNS resolution for the endpoint, as opposed to the
+// sandbox-wide resolv.conf settings configured elsewhere. The value is
+// formatted as "server[,server...][|search[,search...]]", e.g.
+// "10.0.1.2,10.0.1.3|example.internal".
+const endpointDNSDriverOpt = "com.docker.network.endpoint.dns"
+
+// parseEndpointDNSOpt parses the endpointDNSDriverOpt value into the DNS
+// servers and search domains to scope to this endpoint.
+func parseEndpointDNSOpt(opt string) (lntypes.EndpointDNS, error) {
+	var epDNS lntypes.EndpointDNS
+
+	servers, search, _ := strings.Cut(opt, "|")
+
+	for _, s := range strings.Split(servers, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return lntypes.EndpointDNS{}, fmt.Errorf("invalid DNS server address: %s", s)
+		}
+		epDNS.Servers = append(epDNS.Servers, ip)
+	}
+
+	for _, d := range strings.Split(search, ",") {
+		d = strings.TrimSpace(d)
+		if d != "" {
+			epDNS.SearchDomains = append(epDNS.SearchDomains, d)
+		}
+	}
+
+	return epDNS, nil
+}
+
+// endpointDeterministicDriverOpt is the network driver option that opts
+// endpoints on that network into deterministic MAC/IP allocation: the
+// option's value is an arbitrary seed string, e.g.
+// "com.docker.network.endpoint.deterministic=redeploy-v1". With it set,
+// recreating a container with the same name on the same network reuses the
+// same addresses instead of requiring the operator to hand-assign them.
+const endpointDeterministicDriverOpt = "com.docker.network.endpoint.deterministic"
+
+// maxDeterministicAddressAttempts bounds how many counter-suffixed
+// candidates applyDeterministicAddresses will try before giving up, in case
+// the derived address keeps landing on the network, gateway, or broadcast
+// address.
+const maxDeterministicAddressAttempts = 8
+
+// applyDeterministicAddresses fills in epConfig's DesiredMacAddress and
+// IPAMConfig addresses (where not already set explicitly) by deriving them
+// from HMAC-SHA256(seed, containerName+networkID), so the same container
+// name reproducibly gets the same addresses on this network across
+// recreations. The derived IP addresses are reserved through the normal
+// IPAM create-options path below, so a genuine collision with another
+// endpoint still surfaces as the usual "address already in use" error.
+func applyDeterministicAddresses(seed, containerName string, n *libnetwork.Network, epConfig *network.EndpointSettings) error {
+	if epConfig.DesiredMacAddress == "" {
+		epConfig.DesiredMacAddress = deterministicMAC(seed, containerName, n.ID()).String()
+	}
+
+	ipv4Info, ipv6Info := n.IpamInfo()
+
+	if epConfig.IPAMConfig == nil {
+		epConfig.IPAMConfig = &networktypes.EndpointIPAMConfig{}
+	}
+
+	if epConfig.IPAMConfig.IPv4Address == "" {
+		for _, info := range ipv4Info {
+			if info.IPAMData.Pool == nil {
+				continue
+			}
+			ip, err := deterministicIP(seed, containerName, n.ID(), info.IPAMData.Pool, info.IPAMData.Gateway)
+			if err != nil {
+				return err
+			}
+			if ip != nil {
+				epConfig.IPAMConfig.IPv4Address = ip.String()
+			}
+			break
+		}
+	}
+
+	if epConfig.IPAMConfig.IPv6Address == "" {
+		for _, info := range ipv6Info {
+			if info.IPAMData.Pool == nil {
+				continue
+			}
+			ip, err := deterministicIP(seed, containerName, n.ID(), info.IPAMData.Pool, info.IPAMData.Gateway)
+			if err != nil {
+				return err
+			}
+			if ip != nil {
+				epConfig.IPAMConfig.IPv6Address = ip.String()
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// deterministicMAC derives a locally administered, unicast MAC address from
+// HMAC-SHA256(seed, containerName+"/"+networkID+"/mac").
+func deterministicMAC(seed, containerName, networkID string) net.HardwareAddr {
+	sum := hmacSum(seed, containerName+"/"+networkID+"/mac")
+	mac := net.HardwareAddr(sum[:6])
+	// Clear the multicast bit and set the locally administered bit, per the
+	// standard convention for generated unicast MAC addresses.
+	mac[0] = (mac[0] | 0x02) & 0xfe
+	return mac
+}
+
+// deterministicIP derives an address within pool from HMAC-SHA256(seed,
+// containerName+"/"+networkID+"/ip#n"), skipping the network, broadcast,
+// and gateway addresses and retrying with an incrementing counter suffix if
+// one of those is hit.
+func deterministicIP(seed, containerName, networkID string, pool, gateway *net.IPNet) (net.IP, error) {
+	ones, bits := pool.Mask.Size()
+	hostBits := bits - ones
+	if hostBits <= 0 {
+		return nil, nil
+	}
+	// For 64 or more host bits (e.g. an IPv6 /64), every 64-bit offset lies
+	// within pool; numHosts stays 0 and stands for 2^64.
+	var numHosts uint64
+	if hostBits < 64 {
+		numHosts = uint64(1) << uint(hostBits)
+	}
+
+	for attempt := 0; attempt < maxDeterministicAddressAttempts; attempt++ {
+		sum := hmacSum(seed, fmt.Sprintf("%s/%s/ip#%d", containerName, networkID, attempt))
+		offset := binary.BigEndian.Uint64(sum[len(sum)-8:])
+		if numHosts != 0 {
+			offset %= numHosts
+		}
+
+		ip := addOffset(pool.IP, offset)
+		if offset == 0 || offset == numHosts-1 {
+			// Network or broadcast address; try the next counter.
+			continue
+		}
+		if gateway != nil && ip.Equal(gateway.IP) {
+			continue
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("could not derive a deterministic address in %s after %d attempts", pool, maxDeterministicAddressAttempts)
+}
+
+// addOffset returns a copy of base with offset added to its integer value.
+func addOffset(base net.IP, offset uint64) net.IP {
+	ip := slices.Clone(base.To16())
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for i := len(ip) - 1; i >= 0 && offset > 0; i-- {
+		sum := uint64(ip[i]) + offset
+		ip[i] = byte(sum)
+		offset = sum >> 8
+	}
+	return ip
+}
+
+// hmacSum returns HMAC-SHA256(seed, message).
+func hmacSum(seed, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
 // buildCreateEndpointOptions builds endpoint options from a given network.
 func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, epConfig *network.EndpointSettings, sb *libnetwork.Sandbox, daemonDNS []string) ([]libnetwork.EndpointOption, error) {
 	var createOptions []libnetwork.EndpointOption
@@ -838,6 +1371,12 @@ func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, e
 	nwName := n.Name()
 
 	if epConfig != nil {
+		if seed := n.DriverOptions()[endpointDeterministicDriverOpt]; seed != "" {
+			if err := applyDeterministicAddresses(seed, c.Name, n, epConfig); err != nil {
+				return nil, fmt.Errorf("invalid %s driver option: %w", endpointDeterministicDriverOpt, err)
+			}
+		}
+
 		if ipam := epConfig.IPAMConfig; ipam != nil {
 			var ipList []net.IP
 			for _, ips := range ipam.LinkLocalIPs {
@@ -863,6 +1402,14 @@ func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, e
 
 		createOptions = append(createOptions, libnetwork.CreateOptionDNSNames(epConfig.DNSNames))
 
+		if dnsOpt, ok := epConfig.DriverOpts[endpointDNSDriverOpt]; ok {
+			epDNS, err := parseEndpointDNSOpt(dnsOpt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s driver option: %w", endpointDNSDriverOpt, err)
+			}
+			createOptions = append(createOptions, libnetwork.CreateOptionEndpointDNS(epDNS))
+		}
+
 		for k, v := range epConfig.DriverOpts {
 			createOptions = append(createOptions, libnetwork.EndpointOptionGeneric(options.Generic{k: v}))
 		}
@@ -887,10 +1434,13 @@ func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, e
 		var portConfigs []*libnetwork.PortConfig
 		for _, portConfig := range svcCfg.ExposedPorts {
 			portConfigs = append(portConfigs, &libnetwork.PortConfig{
-				Name:          portConfig.Name,
-				Protocol:      libnetwork.PortConfig_Protocol(portConfig.Protocol),
-				TargetPort:    portConfig.TargetPort,
-				PublishedPort: portConfig.PublishedPort,
+				Name:                portConfig.Name,
+				Protocol:            libnetwork.PortConfig_Protocol(portConfig.Protocol),
+				TargetPort:          portConfig.TargetPort,
+				PublishedPort:       portConfig.PublishedPort,
+				HealthCheckPath:     portConfig.HealthCheckPath,
+				HealthCheckInterval: portConfig.HealthCheckInterval,
+				LBAlgorithm:         lbAlgorithm(portConfig.LBAlgorithm),
 			})
 		}
 
@@ -910,6 +1460,11 @@ func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, e
 	// On Windows, DNS config is a per-adapter config option whereas on Linux, it's a sandbox-wide parameter; hence why
 	// we're dealing with DNS config both here and in buildSandboxOptions. Following DNS options are only honored by
 	// Windows netdrivers, whereas DNS options in buildSandboxOptions are only honored by Linux netdrivers.
+	//
+	// The CreateOptionEndpointDNS option set above from endpointDNSDriverOpt is the exception: on Linux it is
+	// programmed per-endpoint through systemd-resolved's link-scoped D-Bus API (falling back to a private
+	// resolv.conf + nsswitch shim when resolved isn't running), so a container attached to two user-defined
+	// networks can resolve names in each network's DNS zone from that network's resolver only.
 	if !n.Internal() {
 		if len(c.HostConfig.DNS) > 0 {
 			createOptions = append(createOptions, libnetwork.CreateOptionDNS(c.HostConfig.DNS))
@@ -934,6 +1489,25 @@ func buildCreateEndpointOptions(c *container.Container, n *libnetwork.Network, e
 	return createOptions, nil
 }
 
+const (
+	// portMappingPolicyDriverOpt is the network driver option that selects
+	// how a host port is picked from within a published range
+	// (-p 8000-8100:80). Only the network's driver options are read; an
+	// endpoint's EndpointSettings.DriverOpts does not override it.
+	portMappingPolicyDriverOpt = "com.docker.network.portmap.policy"
+
+	// portMappingPolicySequential allocates the first free port in the
+	// range, starting from the low end. This is the default, matching
+	// today's implicit behavior.
+	portMappingPolicySequential = "sequential"
+	// portMappingPolicyRandom picks a uniformly random free port in the range.
+	portMappingPolicyRandom = "random"
+	// portMappingPolicyHash derives the port from a hash of the container
+	// ID, so the same container reuses the same host port across restarts
+	// as long as it remains free.
+	portMappingPolicyHash = "hash"
+)
+
 // buildPortsRelatedCreateEndpointOptions returns the appropriate endpoint options to apply config related to port
 // mapping and exposed ports.
 func buildPortsRelatedCreateEndpointOptions(c *container.Container, n *libnetwork.Network, sb *libnetwork.Sandbox) ([]libnetwork.EndpointOption, error) {
@@ -967,6 +1541,7 @@ func buildPortsRelatedCreateEndpointOptions(c *container.Container, n *libnetwor
 	var (
 		exposedPorts   []lntypes.TransportPort
 		publishedPorts []lntypes.PortBinding
+		hasPortRange   bool
 	)
 	for _, port := range ports {
 		portProto := lntypes.ParseProtocol(port.Proto())
@@ -985,6 +1560,9 @@ func buildPortsRelatedCreateEndpointOptions(c *container.Container, n *libnetwor
 			if err != nil {
 				return nil, fmt.Errorf("error parsing HostPort value (%s): %w", binding.HostPort, err)
 			}
+			if portEnd > portStart {
+				hasPortRange = true
+			}
 			publishedPorts = append(publishedPorts, lntypes.PortBinding{
 				Proto:       portProto,
 				Port:        portNum,
@@ -1002,10 +1580,52 @@ func buildPortsRelatedCreateEndpointOptions(c *container.Container, n *libnetwor
 		}
 	}
 
-	return []libnetwork.EndpointOption{
+	createOptions := []libnetwork.EndpointOption{
 		libnetwork.CreateOptionPortMapping(publishedPorts),
 		libnetwork.CreateOptionExposedPorts(exposedPorts),
-	}, nil
+	}
+
+	// Only a published port range gives libnetwork's portmapper a choice to
+	// make; a single host port has nothing to allocate a policy against.
+	if hasPortRange {
+		createOptions = append(createOptions, libnetwork.CreateOptionPortMappingPolicy(portMappingPolicy(n), c.ID))
+	}
+
+	return createOptions, nil
+}
+
+// portMappingPolicy resolves the com.docker.network.portmap.policy driver
+// option to one of the known allocation policies, defaulting to
+// portMappingPolicySequential for unset or unrecognized values so existing
+// deployments keep today's allocation order.
+func portMappingPolicy(n *libnetwork.Network) string {
+	switch policy := n.DriverOptions()[portMappingPolicyDriverOpt]; policy {
+	case portMappingPolicyRandom, portMappingPolicyHash:
+		return policy
+	default:
+		return portMappingPolicySequential
+	}
+}
+
+// Published-port load-balancing algorithms for the L7 VIP proxy. Selecting
+// one of these (via PortConfig.LBAlgorithm) only has an effect when the
+// port's Protocol is one of libnetwork's L7 modes (http, http2, grpc); L4
+// ports are always balanced by libnetwork's IPVS backend.
+const (
+	lbAlgorithmRoundRobin = "round-robin"
+	lbAlgorithmLeastConn  = "least-conn"
+	lbAlgorithmRingHash   = "ring-hash"
+)
+
+// lbAlgorithm normalizes a requested PortConfig.LBAlgorithm value, defaulting
+// to lbAlgorithmRoundRobin for unset or unrecognized values.
+func lbAlgorithm(algorithm string) string {
+	switch algorithm {
+	case lbAlgorithmLeastConn, lbAlgorithmRingHash:
+		return algorithm
+	default:
+		return lbAlgorithmRoundRobin
+	}
 }
 
 // getPortMapInfo retrieves the current port-mapping programmed for the given sandbox
@@ -1066,6 +1686,24 @@ func getEndpointPortMapInfo(pm nat.PortMap, ep *libnetwork.Endpoint) {
 	}
 }
 
+// serviceBackendHealth reports, for an L7 load-balanced published port, the
+// health of each backend endpoint as last observed by the VIP proxy's
+// health checks. It's keyed by the backend endpoint's address, with a value
+// of "healthy" or "unhealthy"; ports without an L7 proxy (or not yet health
+// checked) report no entries.
+//
+// TODO: surface this through container inspect once NetworkSettings grows a
+// field for it; for now it's only available to in-process callers such as
+// future CLI/API plumbing.
+func serviceBackendHealth(ep *libnetwork.Endpoint) map[string]string {
+	driverInfo, _ := ep.DriverInfo()
+	if driverInfo == nil {
+		return nil
+	}
+	health, _ := driverInfo[netlabel.ServiceLBBackendHealth].(map[string]string)
+	return health
+}
+
 // buildEndpointInfo sets endpoint-related fields on container.NetworkSettings based on the provided network and endpoint.
 func buildEndpointInfo(networkSettings *network.Settings, n *libnetwork.Network, ep *libnetwork.Endpoint) error {
 	if ep == nil {
@@ -1132,6 +1770,15 @@ func buildJoinOptions(settings *network.Settings, n interface{ Name() string })
 			return nil, err
 		}
 		joinOptions = append(joinOptions, libnetwork.CreateOptionAlias(name, alias))
+
+		// JoinOptionRemoteAlias lets the link resolve across hosts on a
+		// swarm/overlay network: it subscribes the sandbox resolver to
+		// networkdb for (container-name, endpoint-IP, network-id) tuples
+		// gossiped by other nodes, so if name isn't a local endpoint the
+		// resolver falls back to the remote address and keeps the
+		// sandbox's hosts file current as that endpoint moves, without
+		// requiring a container restart.
+		joinOptions = append(joinOptions, libnetwork.JoinOptionRemoteAlias(name, alias))
 	}
 	for k, v := range epConfig.DriverOpts {
 		joinOptions = append(joinOptions, libnetwork.EndpointOptionGeneric(options.Generic{k: v}))
@@ -1,19 +1,85 @@
 package runconfig
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/network"
 )
 
+// Default decoder limits, used whenever a ContainerDecoder leaves the
+// corresponding field unset (zero value). These exist so a malicious or
+// misconfigured client can't push an unbounded CreateRequest body (huge
+// Env/Mounts/Labels, or deeply nested JSON) into daemon memory before any
+// field validation ever runs.
+const (
+	defaultMaxBodyBytes  int64 = 10 * 1024 * 1024 // 10MiB
+	defaultMaxDepth            = 32
+	defaultMaxLabelCount       = 4096
+)
+
 // ContainerDecoder implements httputils.ContainerDecoder
 // calling DecodeContainerConfig.
 type ContainerDecoder struct {
 	GetSysInfo func() *sysinfo.SysInfo
+
+	// MaxBodyBytes caps the number of bytes read from the request body.
+	// A request exceeding this is rejected before it is ever parsed as
+	// JSON. Zero means defaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// MaxDepth caps how deeply nested the request JSON may be. Zero means
+	// defaultMaxDepth.
+	MaxDepth int
+	// MaxLabelCount caps the number of entries in Config.Labels. Zero
+	// means defaultMaxLabelCount.
+	MaxLabelCount int
+	// DisallowUnknownFields causes the JSON decoder to reject fields that
+	// don't map onto the target struct, instead of silently ignoring them.
+	DisallowUnknownFields bool
+
+	// APIVersion is the API version the client declared for this request
+	// (e.g. via the URL's /v1.24/... prefix). DecodeConfigV2 uses it to
+	// decide which deprecated/removed fields to warn about. Empty means
+	// "assume the latest version" - no version-specific warnings fire.
+	APIVersion string
+}
+
+// decodeLimits is the resolved (defaults-applied) form of the size/shape
+// limits above, threaded through to loadJSON so it doesn't need a
+// ContainerDecoder receiver.
+type decodeLimits struct {
+	maxBodyBytes          int64
+	maxDepth              int
+	maxLabelCount         int
+	disallowUnknownFields bool
+}
+
+// limits resolves r's fields to concrete values, substituting defaults for
+// any left at their zero value.
+func (r ContainerDecoder) limits() decodeLimits {
+	l := decodeLimits{
+		maxBodyBytes:          r.MaxBodyBytes,
+		maxDepth:              r.MaxDepth,
+		maxLabelCount:         r.MaxLabelCount,
+		disallowUnknownFields: r.DisallowUnknownFields,
+	}
+	if l.maxBodyBytes <= 0 {
+		l.maxBodyBytes = defaultMaxBodyBytes
+	}
+	if l.maxDepth <= 0 {
+		l.maxDepth = defaultMaxDepth
+	}
+	if l.maxLabelCount <= 0 {
+		l.maxLabelCount = defaultMaxLabelCount
+	}
+	return l
 }
 
 // DecodeConfig makes ContainerDecoder to implement httputils.ContainerDecoder
@@ -25,7 +91,158 @@ func (r ContainerDecoder) DecodeConfig(src io.Reader) (*container.Config, *conta
 		si = sysinfo.New()
 	}
 
-	return decodeContainerConfig(src, si)
+	return decodeContainerConfig(src, si, r.limits())
+}
+
+// DecodeConfigStrict is DecodeConfig, except that instead of returning the
+// first validation failure it runs every validator and returns all of them
+// at once via ValidationErrors, so a client can fix every offending field
+// in one round trip instead of discovering them one at a time.
+func (r ContainerDecoder) DecodeConfigStrict(src io.Reader) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	var si *sysinfo.SysInfo
+	if r.GetSysInfo != nil {
+		si = r.GetSysInfo()
+	} else {
+		si = sysinfo.New()
+	}
+
+	return decodeContainerConfigStrict(src, si, r.limits())
+}
+
+// FormatDecodeFunc parses src, encoded in some non-Docker-native format,
+// into the same Config/HostConfig/NetworkingConfig triple DecodeConfig
+// produces, so callers downstream of decoding never need to know the
+// original request encoding. Implementations must parse src via loadJSON
+// (passing through the limits argument unchanged), not json.NewDecoder
+// directly, so every registered format gets the same body size/nesting
+// depth protection as the built-in container.CreateRequest decoding.
+type FormatDecodeFunc func(src io.Reader, si *sysinfo.SysInfo, limits decodeLimits) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatDecodeFunc{}
+)
+
+// RegisterFormat registers fn to handle DecodeConfigAs(mediaType, ...).
+// Registering the same mediaType twice replaces the previous registration.
+//
+// This is a package-level registry, mirroring image.RegisterFormat or
+// sql.Register, rather than a field on ContainerDecoder: ContainerDecoder
+// values are typically constructed fresh per request (see GetSysInfo) and
+// carry no identity of their own that a registered format would need to be
+// scoped to.
+func RegisterFormat(mediaType string, fn FormatDecodeFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[mediaType] = fn
+}
+
+// DecodeConfigAs is DecodeConfig, except it dispatches on mediaType
+// (typically the request's Content-Type) to a decoder registered via
+// RegisterFormat, so the HTTP layer can accept alternate request encodings.
+// An empty mediaType, or mediaTypeDockerCreateRequest, uses the built-in
+// container.CreateRequest decoding.
+func (r ContainerDecoder) DecodeConfigAs(mediaType string, src io.Reader) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	var si *sysinfo.SysInfo
+	if r.GetSysInfo != nil {
+		si = r.GetSysInfo()
+	} else {
+		si = sysinfo.New()
+	}
+
+	if mediaType == "" || mediaType == mediaTypeDockerCreateRequest {
+		return decodeContainerConfig(src, si, r.limits())
+	}
+
+	formatsMu.RLock()
+	fn, ok := formats[mediaType]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, nil, nil, validationError(fmt.Sprintf("unsupported request media type %q", mediaType))
+	}
+
+	limits := r.limits()
+	cfg, hc, nc, err := fn(src, si, limits)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkLabelCount(cfg, limits); err != nil {
+		return nil, nil, nil, err
+	}
+	// Adapters only translate field shapes; they don't know which values
+	// are actually valid on this daemon's platform, so run the same
+	// validate* helpers DecodeConfig does regardless of input format.
+	if err := validateDecoded(cfg, hc, si); err != nil {
+		return nil, nil, nil, err
+	}
+	if cfg != nil && cfg.Volumes == nil {
+		cfg.Volumes = make(map[string]struct{})
+	}
+	return cfg, hc, nc, nil
+}
+
+// mediaTypeDockerCreateRequest is the implicit media type of the built-in
+// container.CreateRequest JSON body DecodeConfig has always accepted.
+const mediaTypeDockerCreateRequest = "application/vnd.docker.container.createrequest+json"
+
+// validateDecoded runs the same HostConfig validation decodeContainerConfig
+// performs, extracted so alternate-format adapters registered via
+// RegisterFormat get identical platform-specific checks.
+func validateDecoded(c *container.Config, hc *container.HostConfig, si *sysinfo.SysInfo) error {
+	if hc == nil {
+		return nil
+	}
+	if runtime.GOOS != "windows" && hc.NetworkMode == "" {
+		hc.NetworkMode = network.NetworkDefault
+	}
+	if err := validateNetMode(c, hc); err != nil {
+		return err
+	}
+	if err := validateIsolation(hc); err != nil {
+		return err
+	}
+	if err := validateQoS(hc); err != nil {
+		return err
+	}
+	if err := validateResources(hc, si); err != nil {
+		return err
+	}
+	if err := validatePrivileged(hc); err != nil {
+		return err
+	}
+	return validateReadonlyRootfs(hc)
+}
+
+// FieldError is one validation failure attached to a stable JSON field path
+// (e.g. "HostConfig.NetworkMode"), as collected by decodeContainerConfigStrict.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Err.Error() }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ValidationErrors is every FieldError collected by decodeContainerConfigStrict,
+// implementing Unwrap() []error so callers can use errors.Is/errors.As against
+// any one of them, and reported together so an API handler can render a 400
+// response listing every offending field instead of just the first.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("%d validation error(s): %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, fe := range e {
+		errs = append(errs, fe)
+	}
+	return errs
 }
 
 // decodeContainerConfig decodes a json encoded [container.CreateRequest] struct
@@ -34,9 +251,12 @@ func (r ContainerDecoder) DecodeConfig(src io.Reader) (*container.Config, *conta
 // on the client, as only the daemon knows what is valid for the platform.
 // Be aware this function is not checking whether the resulted structs are nil,
 // it's your business to do so
-func decodeContainerConfig(src io.Reader, si *sysinfo.SysInfo) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+func decodeContainerConfig(src io.Reader, si *sysinfo.SysInfo, limits decodeLimits) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
 	var w container.CreateRequest
-	if err := loadJSON(src, &w); err != nil {
+	if err := loadJSON(src, &w, limits); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkLabelCount(w.Config, limits); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -77,15 +297,129 @@ func decodeContainerConfig(src io.Reader, si *sysinfo.SysInfo) (*container.Confi
 	return w.Config, hc, w.NetworkingConfig, nil
 }
 
-// loadJSON is similar to api/server/httputils.ReadJSON()
-func loadJSON(src io.Reader, out interface{}) error {
-	dec := json.NewDecoder(src)
-	if err := dec.Decode(&out); err != nil {
+// decodeContainerConfigStrict is decodeContainerConfig, except that instead
+// of returning on the first validate* failure it runs every validator and
+// collects every failure into a ValidationErrors.
+//
+// Each validate* helper still returns a single plain error about the whole
+// HostConfig rather than a field-scoped one, so the field path attached here
+// is at the granularity of "which validator failed" (e.g.
+// "HostConfig.Resources" for validateResources) rather than the specific
+// struct field inside it (e.g. "HostConfig.Resources.NanoCPUs") - narrowing
+// further would mean changing those helpers' signatures to accept an
+// accumulator themselves; they keep their single-error signatures, which
+// decodeContainerConfig and validateDecoded also call.
+func decodeContainerConfigStrict(src io.Reader, si *sysinfo.SysInfo, limits decodeLimits) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	var w container.CreateRequest
+	if err := loadJSON(src, &w, limits); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkLabelCount(w.Config, limits); err != nil {
+		return nil, nil, nil, err
+	}
+
+	hc := w.HostConfig
+	if hc == nil {
+		// We may not be passed a host config, such as in the case of docker commit
+		return w.Config, hc, w.NetworkingConfig, nil
+	}
+
+	if runtime.GOOS != "windows" && hc.NetworkMode == "" {
+		hc.NetworkMode = network.NetworkDefault
+	}
+
+	var errs ValidationErrors
+	collect := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, &FieldError{Field: field, Err: err})
+		}
+	}
+	collect("HostConfig.NetworkMode", validateNetMode(w.Config, hc))
+	collect("HostConfig.Isolation", validateIsolation(hc))
+	collect("HostConfig.Resources", validateQoS(hc))
+	collect("HostConfig.Resources", validateResources(hc, si))
+	collect("HostConfig.Privileged", validatePrivileged(hc))
+	collect("HostConfig.ReadonlyRootfs", validateReadonlyRootfs(hc))
+	if len(errs) > 0 {
+		return nil, nil, nil, errs
+	}
+
+	if w.Config != nil && w.Config.Volumes == nil {
+		w.Config.Volumes = make(map[string]struct{})
+	}
+	return w.Config, hc, w.NetworkingConfig, nil
+}
+
+// loadJSON is similar to api/server/httputils.ReadJSON(), except that it
+// enforces limits on the size and shape of src before handing it to the
+// JSON decoder: the body is capped at limits.maxBodyBytes, and the document
+// is rejected if it nests deeper than limits.maxDepth. Both are checked
+// against the raw bytes before the typed Decode runs, so an oversized or
+// pathologically nested payload never reaches it.
+func loadJSON(src io.Reader, out interface{}, limits decodeLimits) error {
+	lr := &io.LimitedReader{R: src, N: limits.maxBodyBytes + 1}
+	body, err := io.ReadAll(lr)
+	if err != nil {
 		// invalidJSONError allows unwrapping the error to detect io.EOF etc.
 		return invalidJSONError{error: err}
 	}
+	if lr.N <= 0 {
+		return validationError(fmt.Sprintf("request body exceeds the maximum allowed size of %d bytes", limits.maxBodyBytes))
+	}
+
+	if depth, ok := jsonMaxDepth(body); ok && depth > limits.maxDepth {
+		return validationError(fmt.Sprintf("request body nesting depth %d exceeds the maximum allowed depth of %d", depth, limits.maxDepth))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if limits.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&out); err != nil {
+		return invalidJSONError{error: err}
+	}
 	if dec.More() {
 		return validationError("unexpected content after JSON")
 	}
 	return nil
 }
+
+// jsonMaxDepth walks body's JSON token stream and reports the deepest object
+// or array nesting level reached. ok is false if body isn't valid JSON, in
+// which case the caller's own typed Decode is left to surface that error.
+func jsonMaxDepth(body []byte) (depth int, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var cur, max int
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			// Token reports a document cut off inside an object or array as
+			// a plain io.EOF too, so only a balanced stream counts as valid.
+			return max, cur == 0
+		}
+		if err != nil {
+			return 0, false
+		}
+		if d, isDelim := tok.(json.Delim); isDelim {
+			switch d {
+			case '{', '[':
+				cur++
+				if cur > max {
+					max = cur
+				}
+			case '}', ']':
+				cur--
+			}
+		}
+	}
+}
+
+// checkLabelCount rejects a Config whose Labels map has grown past
+// limits.maxLabelCount, so a client can't force the daemon to allocate an
+// arbitrarily large map before validation runs.
+func checkLabelCount(c *container.Config, limits decodeLimits) error {
+	if c == nil || len(c.Labels) <= limits.maxLabelCount {
+		return nil
+	}
+	return validationError(fmt.Sprintf("number of labels (%d) exceeds the maximum allowed (%d)", len(c.Labels), limits.maxLabelCount))
+}
@@ -0,0 +1,95 @@
+package runconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestJSONMaxDepth(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		wantDepth int
+		wantOK    bool
+	}{
+		{"flat object", `{"a":1,"b":2}`, 1, true},
+		{"nested object", `{"a":{"b":{"c":1}}}`, 3, true},
+		{"nested array", `[[[1,2],[3]]]`, 3, true},
+		{"scalar", `"hello"`, 0, true},
+		{"invalid json", `{"a":`, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			depth, ok := jsonMaxDepth([]byte(tc.body))
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if ok && depth != tc.wantDepth {
+				t.Errorf("depth = %d, want %d", depth, tc.wantDepth)
+			}
+		})
+	}
+}
+
+func TestLoadJSONEnforcesMaxDepth(t *testing.T) {
+	limits := decodeLimits{maxBodyBytes: defaultMaxBodyBytes, maxDepth: 2, maxLabelCount: defaultMaxLabelCount}
+
+	var out map[string]interface{}
+	err := loadJSON(strings.NewReader(`{"a":{"b":{"c":1}}}`), &out, limits)
+	if err == nil {
+		t.Fatal("expected an error for a body nested deeper than maxDepth")
+	}
+	if !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("expected a nesting-depth error, got: %v", err)
+	}
+}
+
+func TestLoadJSONEnforcesMaxBodyBytes(t *testing.T) {
+	limits := decodeLimits{maxBodyBytes: 4, maxDepth: defaultMaxDepth, maxLabelCount: defaultMaxLabelCount}
+
+	var out map[string]interface{}
+	err := loadJSON(strings.NewReader(`{"a":1}`), &out, limits)
+	if err == nil {
+		t.Fatal("expected an error for a body exceeding maxBodyBytes")
+	}
+	if !strings.Contains(err.Error(), "maximum allowed size") {
+		t.Errorf("expected a body-size error, got: %v", err)
+	}
+}
+
+func TestLoadJSONWithinLimitsSucceeds(t *testing.T) {
+	limits := decodeLimits{maxBodyBytes: defaultMaxBodyBytes, maxDepth: defaultMaxDepth, maxLabelCount: defaultMaxLabelCount}
+
+	var out map[string]interface{}
+	if err := loadJSON(strings.NewReader(`{"a":{"b":1}}`), &out, limits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out["a"] == nil {
+		t.Errorf("expected decoded output to contain key %q", "a")
+	}
+}
+
+func TestCheckLabelCount(t *testing.T) {
+	limits := decodeLimits{maxLabelCount: 2}
+
+	if err := checkLabelCount(nil, limits); err != nil {
+		t.Errorf("nil config should never fail: %v", err)
+	}
+
+	ok := &container.Config{Labels: map[string]string{"a": "1", "b": "2"}}
+	if err := checkLabelCount(ok, limits); err != nil {
+		t.Errorf("config at the limit should pass: %v", err)
+	}
+
+	tooMany := &container.Config{Labels: map[string]string{"a": "1", "b": "2", "c": "3"}}
+	err := checkLabelCount(tooMany, limits)
+	if err == nil {
+		t.Fatal("expected an error for a label count over the limit")
+	}
+	if !strings.Contains(err.Error(), "number of labels") {
+		t.Errorf("expected a label-count error, got: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package runconfig
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	units "github.com/docker/go-units"
+
+	"github.com/docker/docker/pkg/sysinfo"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/api/types/strslice"
+)
+
+// mediaTypeComposeService is the media type a client submits a single
+// Compose service definition as (e.g. the "app:" entry of a compose file,
+// already resolved to one service rather than the whole document).
+const mediaTypeComposeService = "application/vnd.docker.compose.service+json"
+
+func init() {
+	RegisterFormat(mediaTypeComposeService, decodeComposeService)
+}
+
+// composeService is the subset of a Compose service definition this adapter
+// decodes: image, command, environment, ports, volumes, and
+// deploy.resources.limits (cpus and memory). Ports is decoded but not
+// translated: decodeComposeService sets no ExposedPorts or PortBindings.
+// It is not a full Compose schema implementation.
+type composeService struct {
+	Image       string      `json:"image"`
+	Command     interface{} `json:"command"` // string or []string, per the Compose spec
+	Environment interface{} `json:"environment"`
+	Ports       []string    `json:"ports"`
+	Volumes     []string    `json:"volumes"`
+	Deploy      *struct {
+		Resources *struct {
+			Limits *struct {
+				CPUs   string `json:"cpus"`
+				Memory string `json:"memory"`
+			} `json:"limits"`
+		} `json:"resources"`
+	} `json:"deploy"`
+}
+
+// decodeComposeService maps a single Compose service definition onto the
+// Config/HostConfig/NetworkingConfig triple the rest of the daemon expects.
+func decodeComposeService(src io.Reader, si *sysinfo.SysInfo, limits decodeLimits) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	var svc composeService
+	if err := loadJSON(src, &svc, limits); err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := &container.Config{
+		Image: svc.Image,
+		Cmd:   strslice.StrSlice(composeCommand(svc.Command)),
+		Env:   composeEnvironment(svc.Environment),
+	}
+	hc := &container.HostConfig{
+		Binds: svc.Volumes,
+	}
+
+	if svc.Deploy != nil && svc.Deploy.Resources != nil && svc.Deploy.Resources.Limits != nil {
+		limits := svc.Deploy.Resources.Limits
+		if limits.Memory != "" {
+			if v, err := units.RAMInBytes(limits.Memory); err == nil {
+				hc.Resources.Memory = v
+			}
+		}
+		if limits.CPUs != "" {
+			if v, err := strconv.ParseFloat(limits.CPUs, 64); err == nil {
+				hc.Resources.NanoCPUs = int64(v * 1e9)
+			}
+		}
+	}
+
+	return cfg, hc, nil, nil
+}
+
+// composeCommand normalizes Compose's "command" field, which the spec
+// allows to be either a single string (shell-split) or a list of strings.
+func composeCommand(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// composeEnvironment normalizes Compose's "environment" field, which the
+// spec allows to be either a "KEY=VALUE" list or a map of key to value.
+func composeEnvironment(v interface{}) []string {
+	switch t := v.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case map[string]interface{}:
+		out := make([]string, 0, len(t))
+		for k, e := range t {
+			if s, ok := e.(string); ok {
+				out = append(out, k+"="+s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package runconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeConfigAsComposeEnforcesLimits is a regression test: decodeComposeService
+// used to call json.NewDecoder(src).Decode directly, bypassing the
+// MaxBodyBytes/MaxDepth/MaxLabelCount protections DecodeConfigAs applies to
+// the built-in container.CreateRequest format. It now goes through loadJSON
+// like every other decode path.
+func TestDecodeConfigAsComposeEnforcesLimits(t *testing.T) {
+	r := ContainerDecoder{MaxDepth: 1}
+
+	body := `{"image":"alpine","deploy":{"resources":{"limits":{"memory":"1g"}}}}`
+	_, _, _, err := r.DecodeConfigAs(mediaTypeComposeService, strings.NewReader(body))
+	if err == nil {
+		t.Fatal("expected an error for a body nested deeper than MaxDepth")
+	}
+	if !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("expected a nesting-depth error, got: %v", err)
+	}
+}
+
+func TestDecodeConfigAsComposeEnforcesMaxBodyBytes(t *testing.T) {
+	r := ContainerDecoder{MaxBodyBytes: 4}
+
+	body := `{"image":"alpine"}`
+	_, _, _, err := r.DecodeConfigAs(mediaTypeComposeService, strings.NewReader(body))
+	if err == nil {
+		t.Fatal("expected an error for a body exceeding MaxBodyBytes")
+	}
+	if !strings.Contains(err.Error(), "maximum allowed size") {
+		t.Errorf("expected a body-size error, got: %v", err)
+	}
+}
+
+func TestDecodeConfigAsComposeWithinLimitsSucceeds(t *testing.T) {
+	r := ContainerDecoder{}
+
+	body := `{"image":"alpine","command":"true","environment":["A=1"]}`
+	cfg, _, _, err := r.DecodeConfigAs(mediaTypeComposeService, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Image != "alpine" {
+		t.Errorf("Image = %q, want %q", cfg.Image, "alpine")
+	}
+}
@@ -0,0 +1,121 @@
+package runconfig
+
+import (
+	"io"
+
+	"github.com/docker/docker/pkg/sysinfo"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/api/types/strslice"
+)
+
+// mediaTypeOCIRuntimeSpec is the media type a client submits a config.json
+// as, per the OCI Runtime Specification's bundle layout.
+const mediaTypeOCIRuntimeSpec = "application/vnd.oci.runtime.config.v1+json"
+
+func init() {
+	RegisterFormat(mediaTypeOCIRuntimeSpec, decodeOCIRuntimeSpec)
+}
+
+// ociRuntimeSpec is the subset of an OCI runtime config.json this adapter
+// understands: process, mounts, and the linux.resources/linux.namespaces
+// fields called out by the request this adapter was added for. It is not a
+// full implementation of the OCI Runtime Specification.
+type ociRuntimeSpec struct {
+	Process *struct {
+		Args []string `json:"args"`
+		Env  []string `json:"env"`
+		Cwd  string   `json:"cwd"`
+	} `json:"process"`
+	Mounts []struct {
+		Destination string   `json:"destination"`
+		Source      string   `json:"source"`
+		Type        string   `json:"type"`
+		Options     []string `json:"options"`
+	} `json:"mounts"`
+	Linux *struct {
+		Resources *struct {
+			Memory *struct {
+				Limit *int64 `json:"limit"`
+			} `json:"memory"`
+			CPU *struct {
+				Shares *uint64 `json:"shares"`
+				Quota  *int64  `json:"quota"`
+				Period *uint64 `json:"period"`
+				Cpus   string  `json:"cpus"`
+				Mems   string  `json:"mems"`
+			} `json:"cpu"`
+		} `json:"resources"`
+		Namespaces []struct {
+			Type string `json:"type"`
+			Path string `json:"path"`
+		} `json:"namespaces"`
+	} `json:"linux"`
+}
+
+// decodeOCIRuntimeSpec maps an OCI runtime config.json onto the
+// Config/HostConfig/NetworkingConfig triple the rest of the daemon expects.
+// Only process, mounts, linux.resources, and linux.namespaces are
+// translated, matching the fields the request asked this adapter to cover.
+func decodeOCIRuntimeSpec(src io.Reader, si *sysinfo.SysInfo, limits decodeLimits) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	var spec ociRuntimeSpec
+	if err := loadJSON(src, &spec, limits); err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := &container.Config{}
+	hc := &container.HostConfig{}
+
+	if p := spec.Process; p != nil {
+		cfg.Cmd = strslice.StrSlice(p.Args)
+		cfg.Env = p.Env
+		cfg.WorkingDir = p.Cwd
+	}
+
+	for _, m := range spec.Mounts {
+		hc.Mounts = append(hc.Mounts, mount.Mount{
+			Type:     mount.Type(m.Type),
+			Source:   m.Source,
+			Target:   m.Destination,
+			ReadOnly: containsString(m.Options, "ro"),
+		})
+	}
+
+	if l := spec.Linux; l != nil {
+		if r := l.Resources; r != nil {
+			if r.Memory != nil && r.Memory.Limit != nil {
+				hc.Resources.Memory = *r.Memory.Limit
+			}
+			if r.CPU != nil {
+				if r.CPU.Shares != nil {
+					hc.Resources.CPUShares = int64(*r.CPU.Shares)
+				}
+				if r.CPU.Quota != nil {
+					hc.Resources.CPUQuota = *r.CPU.Quota
+				}
+				if r.CPU.Period != nil {
+					hc.Resources.CPUPeriod = int64(*r.CPU.Period)
+				}
+				hc.Resources.CpusetCpus = r.CPU.Cpus
+				hc.Resources.CpusetMems = r.CPU.Mems
+			}
+		}
+		for _, ns := range l.Namespaces {
+			if ns.Type == "network" && ns.Path != "" {
+				hc.NetworkMode = container.NetworkMode("container:" + ns.Path)
+			}
+		}
+	}
+
+	return cfg, hc, nil, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package runconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeConfigAsOCIEnforcesLimits is a regression test: decodeOCIRuntimeSpec
+// used to call json.NewDecoder(src).Decode directly, bypassing the
+// MaxBodyBytes/MaxDepth/MaxLabelCount protections DecodeConfigAs applies to
+// the built-in container.CreateRequest format. It now goes through loadJSON
+// like every other decode path.
+func TestDecodeConfigAsOCIEnforcesLimits(t *testing.T) {
+	r := ContainerDecoder{MaxDepth: 2}
+
+	body := `{"process":{"args":["sh"]},"linux":{"resources":{"memory":{"limit":1}}}}`
+	_, _, _, err := r.DecodeConfigAs(mediaTypeOCIRuntimeSpec, strings.NewReader(body))
+	if err == nil {
+		t.Fatal("expected an error for a body nested deeper than MaxDepth")
+	}
+	if !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("expected a nesting-depth error, got: %v", err)
+	}
+}
+
+func TestDecodeConfigAsOCIEnforcesMaxBodyBytes(t *testing.T) {
+	r := ContainerDecoder{MaxBodyBytes: 4}
+
+	body := `{"process":{"args":["sh"]}}`
+	_, _, _, err := r.DecodeConfigAs(mediaTypeOCIRuntimeSpec, strings.NewReader(body))
+	if err == nil {
+		t.Fatal("expected an error for a body exceeding MaxBodyBytes")
+	}
+	if !strings.Contains(err.Error(), "maximum allowed size") {
+		t.Errorf("expected a body-size error, got: %v", err)
+	}
+}
+
+func TestDecodeConfigAsOCIWithinLimitsSucceeds(t *testing.T) {
+	r := ContainerDecoder{}
+
+	body := `{"process":{"args":["sh","-c","true"],"env":["A=1"],"cwd":"/"}}`
+	cfg, hc, _, err := r.DecodeConfigAs(mediaTypeOCIRuntimeSpec, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Cmd) != 3 || cfg.Cmd[0] != "sh" {
+		t.Errorf("Cmd = %v, want [sh -c true]", cfg.Cmd)
+	}
+	if hc == nil {
+		t.Fatal("expected a non-nil HostConfig")
+	}
+}
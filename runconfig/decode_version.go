@@ -0,0 +1,157 @@
+package runconfig
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/sysinfo"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+)
+
+// DeprecationWarning is one machine-readable notice recorded by
+// DecodeConfigV2 about a HostConfig field the client sent that is
+// deprecated, ignored, or defaulted as of some later API version than the
+// one the client declared. DecodeConfigV2 returns them to its caller; no
+// API handler calls DecodeConfigV2, so they are not added to the Warnings
+// of a create response.
+type DeprecationWarning struct {
+	Field        string `json:"field"`
+	Reason       string `json:"reason"`
+	SinceVersion string `json:"since_version"`
+}
+
+// versionTransform describes how to handle one HostConfig field for clients
+// that declared an API version older than SinceVersion: Check reports
+// whether the client actually set it, and Strip (if non-nil) clears or
+// defaults it so validation downstream doesn't see a value the daemon
+// already treats as meaningless, avoiding an opaque validation error for a
+// field the client never meant to rely on.
+type versionTransform struct {
+	Field        string
+	SinceVersion string
+	Reason       string
+	Check        func(hc *container.HostConfig) bool
+	Strip        func(hc *container.HostConfig)
+}
+
+// versionTransforms is intentionally small: it covers KernelMemory,
+// CPUShares, and the legacy "default" NetworkMode alias, not every
+// deprecated field the real API has accumulated. The CPUShares entry applies
+// whatever the host's cgroup version; it does not check for cgroup v2.
+var versionTransforms = []versionTransform{
+	{
+		Field:        "HostConfig.KernelMemory",
+		SinceVersion: "1.42",
+		Reason:       "kernel memory limits were removed from the daemon in API 1.42 and are ignored",
+		Check:        func(hc *container.HostConfig) bool { return hc.KernelMemory != 0 },
+		Strip:        func(hc *container.HostConfig) { hc.KernelMemory = 0 },
+	},
+	{
+		Field:        "HostConfig.CPUShares",
+		SinceVersion: "1.42",
+		Reason:       "cpu-shares has no effect under cgroup v2; use NanoCPUs or CPUQuota/CPUPeriod instead",
+		Check:        func(hc *container.HostConfig) bool { return hc.CPUShares != 0 },
+		Strip:        func(hc *container.HostConfig) { hc.CPUShares = 0 },
+	},
+	{
+		Field:        "HostConfig.NetworkMode",
+		SinceVersion: "1.24",
+		Reason:       `the "default" NetworkMode alias was replaced by an empty value meaning the platform default`,
+		Check:        func(hc *container.HostConfig) bool { return hc.NetworkMode == "default" },
+		Strip:        func(hc *container.HostConfig) { hc.NetworkMode = "" },
+	},
+}
+
+// DecodeConfigV2 is DecodeConfig, except it also returns a DeprecationWarning
+// for every field in versionTransforms that the client set despite
+// declaring (via r.APIVersion) a version older than that field's
+// SinceVersion. Unlike DecodeConfig, validation runs AFTER versionTransforms
+// has stripped affected fields back to their zero value, not before: running
+// DecodeConfig's validation first would fail the request on the very field
+// this function exists to forgive, so the client would get a hard
+// validation error instead of a warning for a field they never meant to
+// rely on.
+func (r ContainerDecoder) DecodeConfigV2(src io.Reader) (*container.Config, *container.HostConfig, *network.NetworkingConfig, []DeprecationWarning, error) {
+	var si *sysinfo.SysInfo
+	if r.GetSysInfo != nil {
+		si = r.GetSysInfo()
+	} else {
+		si = sysinfo.New()
+	}
+
+	var w container.CreateRequest
+	if err := loadJSON(src, &w, r.limits()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := checkLabelCount(w.Config, r.limits()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	var warnings []DeprecationWarning
+	if w.HostConfig != nil {
+		for _, t := range versionTransforms {
+			if !r.apiVersionOlderThan(t.SinceVersion) {
+				continue
+			}
+			if t.Check == nil || !t.Check(w.HostConfig) {
+				continue
+			}
+			warnings = append(warnings, DeprecationWarning{
+				Field:        t.Field,
+				Reason:       t.Reason,
+				SinceVersion: t.SinceVersion,
+			})
+			if t.Strip != nil {
+				t.Strip(w.HostConfig)
+			}
+		}
+	}
+
+	if err := validateDecoded(w.Config, w.HostConfig, si); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if w.Config != nil && w.Config.Volumes == nil {
+		w.Config.Volumes = make(map[string]struct{})
+	}
+
+	return w.Config, w.HostConfig, w.NetworkingConfig, warnings, nil
+}
+
+// apiVersionOlderThan reports whether r.APIVersion is a valid "major.minor"
+// version string strictly older than since. An empty or unparsable
+// APIVersion is treated as "latest" (not older than anything), so a
+// ContainerDecoder with no declared version never triggers warnings.
+func (r ContainerDecoder) apiVersionOlderThan(since string) bool {
+	cur, ok := parseAPIVersion(r.APIVersion)
+	if !ok {
+		return false
+	}
+	want, ok := parseAPIVersion(since)
+	if !ok {
+		return false
+	}
+	if cur[0] != want[0] {
+		return cur[0] < want[0]
+	}
+	return cur[1] < want[1]
+}
+
+// parseAPIVersion parses a "major.minor" API version string, the only form
+// the Docker API has ever used.
+func parseAPIVersion(v string) (parts [2]int, ok bool) {
+	major, minor, found := strings.Cut(v, ".")
+	if !found {
+		return parts, false
+	}
+	maj, err := strconv.Atoi(major)
+	if err != nil {
+		return parts, false
+	}
+	min, err := strconv.Atoi(minor)
+	if err != nil {
+		return parts, false
+	}
+	return [2]int{maj, min}, true
+}
@@ -0,0 +1,55 @@
+package runconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeConfigV2StripsBeforeValidating is a regression test: DecodeConfigV2
+// used to call the same validation path as DecodeConfig before applying
+// versionTransforms, so an old-API client sending a deprecated field (here,
+// HostConfig.CPUShares under an API version before 1.42) got a hard
+// validation error instead of the DeprecationWarning this function exists to
+// produce. Stripping first means validateDecoded never sees the
+// already-forgiven value.
+func TestDecodeConfigV2StripsBeforeValidating(t *testing.T) {
+	r := ContainerDecoder{APIVersion: "1.41"}
+
+	body := `{"HostConfig":{"CPUShares":512}}`
+	_, hc, _, warnings, err := r.DecodeConfigV2(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("DecodeConfigV2: %v", err)
+	}
+	if hc.CPUShares != 0 {
+		t.Errorf("CPUShares = %d, want 0 (should have been stripped before validation)", hc.CPUShares)
+	}
+
+	var found bool
+	for _, w := range warnings {
+		if w.Field == "HostConfig.CPUShares" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a DeprecationWarning for HostConfig.CPUShares, got %+v", warnings)
+	}
+}
+
+// TestDecodeConfigV2NoWarningForCurrentAPIVersion checks that a client
+// declaring a current API version (1.45, not older than CPUShares'
+// SinceVersion of 1.42) gets neither a warning nor field stripping.
+func TestDecodeConfigV2NoWarningForCurrentAPIVersion(t *testing.T) {
+	r := ContainerDecoder{APIVersion: "1.45"}
+
+	body := `{"HostConfig":{"CPUShares":512}}`
+	_, hc, _, warnings, err := r.DecodeConfigV2(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("DecodeConfigV2: %v", err)
+	}
+	if hc.CPUShares != 512 {
+		t.Errorf("CPUShares = %d, want 512 (should not be stripped for a current API version)", hc.CPUShares)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings for a current API version, got %+v", warnings)
+	}
+}
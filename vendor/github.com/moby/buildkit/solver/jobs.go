@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/metrics"
+	"github.com/moby/buildkit/solver/statestore"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/flightcontrol"
 	"github.com/moby/buildkit/util/progress"
@@ -26,6 +30,11 @@ import (
 // ResolveOpFunc finds an Op implementation for a Vertex
 type ResolveOpFunc func(Vertex, Builder) (Op, error)
 
+// ErrUnknownOpType is returned by a ResolveOpFunc that doesn't recognize a
+// Vertex's op type, so Solver.resolveOp knows to try the next resolver in
+// the chain instead of treating it as a fatal error.
+var ErrUnknownOpType = errors.New("unknown op type")
+
 type Builder interface {
 	Build(ctx context.Context, e Edge) (CachedResultWithProvenance, error)
 	InContext(ctx context.Context, f func(ctx context.Context, g session.Group) error) error
@@ -45,8 +54,76 @@ type Solver struct {
 	updateCond *sync.Cond
 	s          *scheduler
 	index      *edgeIndex
+
+	// resumable holds JobRecords loaded from opts.StateStore on startup,
+	// keyed by JobID, until a matching job is created via
+	// NewJobWithOptions (jl.actives is only populated lazily on first
+	// load(), so there's nothing to repopulate eagerly here).
+	resumable map[string]*statestore.JobRecord
+
+	lastStateStoreGC time.Time
+
+	opRegistryMu sync.Mutex
+	extraOps     []registeredOp
+}
+
+type registeredOp struct {
+	prefix string
+	fn     ResolveOpFunc
+}
+
+// RegisterOp adds an additional ResolveOpFunc to the chain every vertex's
+// getOp consults after opts.ResolveOpFunc, so frontends or third-party ops
+// (a custom Op_Merge, Op_Diff, or an out-of-tree WASM op) can plug in
+// without forking the solver. prefix is recorded for diagnostics only;
+// resolvers are tried in registration order and the first one that doesn't
+// return ErrUnknownOpType wins.
+func (jl *Solver) RegisterOp(prefix string, fn ResolveOpFunc) {
+	jl.opRegistryMu.Lock()
+	defer jl.opRegistryMu.Unlock()
+	jl.extraOps = append(jl.extraOps, registeredOp{prefix: prefix, fn: fn})
+}
+
+// resolveOp is the ResolveOpFunc passed to every sharedOp. It tries
+// opts.ResolveOpFunc first (the module's default op set) and then each
+// RegisterOp'd resolver in registration order, stopping at the first one
+// that returns something other than ErrUnknownOpType. The whole chain runs
+// at most once per vertex since sharedOp.getOp caches the result under
+// opOnce.
+func (jl *Solver) resolveOp(v Vertex, b Builder) (Op, error) {
+	var lastErr error
+	if jl.opts.ResolveOpFunc != nil {
+		op, err := jl.opts.ResolveOpFunc(v, b)
+		if !errors.Is(err, ErrUnknownOpType) {
+			return op, err
+		}
+		lastErr = err
+	}
+	jl.opRegistryMu.Lock()
+	extraOps := append([]registeredOp(nil), jl.extraOps...)
+	jl.opRegistryMu.Unlock()
+	for _, ro := range extraOps {
+		op, err := ro.fn(v, b)
+		if !errors.Is(err, ErrUnknownOpType) {
+			return op, err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = ErrUnknownOpType
+	}
+	return nil, lastErr
 }
 
+// stateStoreGCInterval bounds how often deleteIfUnreferenced triggers an
+// opts.StateStore.GC pass, so a crash that skips the normal Job.Discard
+// delete doesn't leak records forever.
+const stateStoreGCInterval = 10 * time.Minute
+
+// stateStoreGCTTL is how old an orphaned StateStore record must be before
+// GC removes it.
+const stateStoreGCTTL = 24 * time.Hour
+
 type state struct {
 	jobs     map[*Job]struct{}
 	parents  map[digest.Digest]struct{}
@@ -153,7 +230,7 @@ func (s *state) getEdge(index Index) *edge {
 	}
 
 	if s.op == nil {
-		s.op = newSharedOp(s.opts.ResolveOpFunc, s)
+		s.op = newSharedOp(s.solver.resolveOp, s)
 	}
 
 	e := newEdge(Edge{Index: index, Vertex: s.vtx}, s.op, s.index)
@@ -179,7 +256,8 @@ func (s *state) setEdge(index Index, targetEdge *edge, targetState *state) {
 	targetEdge.takeOwnership(e)
 
 	if targetState != nil {
-		targetState.addJobs(s, map[*state]struct{}{})
+		s.opts.Metrics.IncEdgeMerge()
+		targetState.addJobs(s)
 
 		targetState.allPwMu.Lock()
 		if _, ok := targetState.allPw[s.mpw]; !ok {
@@ -190,49 +268,180 @@ func (s *state) setEdge(index Index, targetEdge *edge, targetState *state) {
 	}
 }
 
-// addJobs recursively adds jobs to state and all its ancestors. currently
+// walkStatesConcurrency bounds how many goroutines walkStates runs at once.
+// It's deliberately small: the work done per node (lock+map update, or a
+// progress-writer registration) is cheap, so the benefit is in overlapping
+// the wide fan-in of frontend-generated graphs, not in raw parallelism.
+const walkStatesConcurrency = 8
+
+// walkGraph fans out from roots over the graph reached by next(n), using up
+// to concurrency goroutines, and memoizes visited nodes by key(n) so a node
+// reachable through multiple paths (shared ancestors, merged edges) is only
+// visited once. The caller must already hold Solver.mu (a read lock is
+// sufficient); key, next and visit run while that lock is held by the
+// caller and must never try to re-acquire it. Since visit may now run
+// concurrently for distinct nodes, callers whose visit closes over shared
+// state (as walkProvenance's f does) must synchronize that state themselves
+// - the per-node locking already done inside each visit (e.g. state.mu,
+// Solver.state's op lock) only protects that one node.
+func walkGraph[T any](ctx context.Context, roots []T, concurrency int, key func(T) digest.Digest, next func(T) []T, visit func(T) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		visited sync.Map
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		errOnce sync.Once
+		retErr  error
+	)
+
+	var walk func(n T)
+	walk = func(n T) {
+		defer wg.Done()
+
+		if _, dup := visited.LoadOrStore(key(n), struct{}{}); dup {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			errOnce.Do(func() { retErr = ctx.Err() })
+			return
+		case sem <- struct{}{}:
+		}
+		err := visit(n)
+		<-sem
+		if err != nil {
+			errOnce.Do(func() { retErr = err })
+			return
+		}
+
+		for _, c := range next(n) {
+			wg.Add(1)
+			go walk(c)
+		}
+	}
+
+	for _, r := range roots {
+		wg.Add(1)
+		go walk(r)
+	}
+	wg.Wait()
+
+	return retErr
+}
+
+// walkStates is walkGraph specialized to the *state graph used by addJobs
+// and connectProgressFromState, keyed by each state's vertex digest.
+func walkStates(ctx context.Context, roots []*state, concurrency int, next func(*state) []*state, visit func(*state) error) error {
+	return walkGraph(ctx, roots, concurrency, func(s *state) digest.Digest { return s.vtx.Digest() }, next, visit)
+}
+
+// addJobs adds jobs from srcState to state and all its ancestors. currently
 // only used during edge merges to add jobs from the source of the merge to the
 // target and its ancestors.
 // requires that Solver.mu is read-locked and srcState.mu is locked
-func (s *state) addJobs(srcState *state, memo map[*state]struct{}) {
-	if _, ok := memo[s]; ok {
-		return
-	}
-	memo[s] = struct{}{}
+func (s *state) addJobs(srcState *state) {
+	_ = walkStates(context.Background(), []*state{s}, walkStatesConcurrency, func(cur *state) []*state {
+		var next []*state
+		for _, inputEdge := range cur.vtx.Inputs() {
+			inputState, ok := cur.solver.actives[inputEdge.Vertex.Digest()]
+			if !ok {
+				bklog.G(context.TODO()).
+					WithField("vertex_digest", inputEdge.Vertex.Digest()).
+					Error("input vertex not found during addJobs")
+				continue
+			}
+			next = append(next, inputState)
 
+			// tricky case: if the inputState's edge was *already* merged we should
+			// also add jobs to the merged edge's state
+			mergedInputEdge := inputState.getEdge(inputEdge.Index)
+			if mergedInputEdge == nil || mergedInputEdge.edge.Vertex.Digest() == inputEdge.Vertex.Digest() {
+				// not merged
+				continue
+			}
+			mergedInputState, ok := cur.solver.actives[mergedInputEdge.edge.Vertex.Digest()]
+			if !ok {
+				bklog.G(context.TODO()).
+					WithField("vertex_digest", mergedInputEdge.edge.Vertex.Digest()).
+					Error("merged input vertex not found during addJobs")
+				continue
+			}
+			next = append(next, mergedInputState)
+		}
+		return next
+	}, func(cur *state) error {
+		cur.mu.Lock()
+		defer cur.mu.Unlock()
+		for j := range srcState.jobs {
+			cur.jobs[j] = struct{}{}
+		}
+		return nil
+	})
+}
+
+// priority returns the highest Priority among the jobs currently
+// referencing this state. As jobs merge via addJobs (edge merges sharing a
+// vertex across jobs), this stays up to date since it's computed from
+// s.jobs rather than cached, so a scheduler could call it to decide which
+// state's edges/exec to prefer without needing a separate merge step - no
+// such consumer exists in this package yet (see the Job.Priority doc
+// comment), so this is currently unused introspection rather than an
+// active scheduling input.
+func (s *state) priority() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for j := range srcState.jobs {
-		s.jobs[j] = struct{}{}
+	var p int
+	for j := range s.jobs {
+		if j.Priority > p {
+			p = j.Priority
+		}
 	}
+	return p
+}
 
-	for _, inputEdge := range s.vtx.Inputs() {
-		inputState, ok := s.solver.actives[inputEdge.Vertex.Digest()]
-		if !ok {
-			bklog.G(context.TODO()).
-				WithField("vertex_digest", inputEdge.Vertex.Digest()).
-				Error("input vertex not found during addJobs")
-			continue
-		}
-		inputState.addJobs(srcState, memo)
+// attachedJobIDs returns the IDs of every Job currently referencing this
+// state, sorted for stable output. Progress and trace-span fan-out to these
+// jobs already happens through connectProgressFromState/addJobs (every
+// attaching job's pw/span gets added to s.mpw/s.mspan, and a late attacher
+// is replayed the vertex's current clientVertex snapshot so its UI picks up
+// an already-running vertex as shared), and cancellation of the underlying
+// flightcontrol.Group.Do call is already reference-counted by that package
+// across every caller still in the call. attachedJobIDs exists for callers
+// that need the set itself, e.g. to annotate a shared execution span with
+// the jobs it's shared across.
+func (s *state) attachedJobIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.jobs))
+	for j := range s.jobs {
+		ids = append(ids, j.id)
+	}
+	sort.Strings(ids)
+	return ids
+}
 
-		// tricky case: if the inputState's edge was *already* merged we should
-		// also add jobs to the merged edge's state
-		mergedInputEdge := inputState.getEdge(inputEdge.Index)
-		if mergedInputEdge == nil || mergedInputEdge.edge.Vertex.Digest() == inputEdge.Vertex.Digest() {
-			// not merged
-			continue
-		}
-		mergedInputState, ok := s.solver.actives[mergedInputEdge.edge.Vertex.Digest()]
-		if !ok {
-			bklog.G(context.TODO()).
-				WithField("vertex_digest", mergedInputEdge.edge.Vertex.Digest()).
-				Error("merged input vertex not found during addJobs")
-			continue
+// jobSpanLinks returns an OTel span link for every attached job that has a
+// valid root span, so a shared vertex's execution span can record where it
+// was reached from even though tracing.MultiSpan already forwards span
+// calls to every attached job's span directly.
+func (s *state) jobSpanLinks() []trace.Link {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var links []trace.Link
+	for j := range s.jobs {
+		j.mu.Lock()
+		span := j.span
+		j.mu.Unlock()
+		if span != nil && span.SpanContext().IsValid() {
+			links = append(links, trace.Link{SpanContext: span.SpanContext()})
 		}
-		mergedInputState.addJobs(srcState, memo)
 	}
+	return links
 }
 
 func (s *state) combinedCacheManager() CacheManager {
@@ -313,23 +522,106 @@ type Job struct {
 	progressCloser func(error)
 	SessionID      string
 	uniqueID       string // unique ID is used for provenance. We use a different field that client can't control
+
+	// Priority lets a caller multiplex interactive builds with
+	// background/prewarm jobs on the same Solver: a state's effective
+	// priority (see state.priority) is the highest priority among the jobs
+	// that still reference it. The scheduler that activates edges and
+	// yields already-running exec lives outside this file and doesn't
+	// consult it yet, so today this is exposed for callers that want to
+	// order their own job-level bookkeeping (progress, logging) by
+	// priority, not a guarantee that a shared vertex preempts in
+	// priority order.
+	Priority int
+	// Deadline bounds how long this Job's own Build calls may run: once
+	// set, Build derives its context from Deadline the same way a caller
+	// passing a context.WithDeadline would, so the build fails with
+	// context.DeadlineExceeded instead of running unbounded. It has no
+	// effect on other jobs sharing the same underlying state.
+	Deadline time.Time
+
+	// resumed is the StateStore record this job was reconnected to, if its
+	// ID matched one loaded by NewSolver. A caller can use Job.Resumed to
+	// decide whether to skip re-sending inputs the previous run already
+	// pushed through the session.
+	resumed *statestore.JobRecord
+}
+
+// Resumed reports whether this Job reconnects to state persisted by a
+// previous Solver instance under the same job ID, and the record it
+// resumed from.
+func (j *Job) Resumed() (*statestore.JobRecord, bool) {
+	return j.resumed, j.resumed != nil
+}
+
+// JobOptions configures a Job created via Solver.NewJobWithOptions.
+type JobOptions struct {
+	Priority int
+	Deadline time.Time
 }
 
 type SolverOpt struct {
 	ResolveOpFunc ResolveOpFunc
 	DefaultCache  CacheManager
+	// Metrics receives solver-internal events (active vertex/job counts,
+	// edge merges, cache hit/exec ratios, ...). Defaults to metrics.Noop{}.
+	Metrics metrics.Sink
+	// StateStore, if set, checkpoints job state so a client reconnecting
+	// with the same job ID after a Solver.Close or daemon crash can resume
+	// instead of restarting the build. Resuming is best-effort: a missing
+	// or stale record just results in a normal fresh build.
+	StateStore statestore.Store
+	// Dispatcher, if set, is consulted by sharedOp.Exec after a CacheMap/
+	// LoadCache miss to decide whether a vertex should run locally or be
+	// handed to a remote worker. Locally-dispatched vertices behave
+	// exactly as before; this turns a single Solver into the coordinator
+	// of a horizontally scalable exec farm.
+	Dispatcher Dispatcher
+}
+
+// Dispatcher picks where a vertex's Exec should run.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, vtx Vertex, inputs []Result) (Location, error)
+}
+
+// Location is the result of a Dispatch call.
+type Location struct {
+	// Remote is nil for a local Exec (the zero value), or the executor to
+	// stream the vertex and its inputs to otherwise.
+	Remote RemoteExecutor
+}
+
+// RemoteExecutor runs a vertex's op on a peer worker and returns the
+// produced results (already importable into the local CacheManager so a
+// subsequent state.combinedCacheManager lookup finds them) plus any
+// exporters it collected.
+type RemoteExecutor interface {
+	Exec(ctx context.Context, vtx Vertex, inputs []Result) ([]Result, []ExportableCacheKey, error)
 }
 
 func NewSolver(opts SolverOpt) *Solver {
 	if opts.DefaultCache == nil {
 		opts.DefaultCache = NewInMemoryCacheManager()
 	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.Noop{}
+	}
 	jl := &Solver{
 		jobs:    make(map[string]*Job),
 		actives: make(map[digest.Digest]*state),
 		opts:    opts,
 		index:   newEdgeIndex(),
 	}
+	if opts.StateStore != nil {
+		if recs, err := opts.StateStore.List(context.Background()); err == nil {
+			jl.resumable = make(map[string]*statestore.JobRecord, len(recs))
+			for i, rec := range recs {
+				jl.resumable[rec.JobID] = &recs[i]
+			}
+		} else {
+			bklog.G(context.Background()).WithError(err).Error("failed to replay solver state store")
+		}
+	}
 	jl.s = newScheduler(jl)
 	jl.updateCond = sync.NewCond(jl.mu.RLocker())
 	return jl
@@ -519,6 +811,7 @@ func (jl *Solver) loadUnlocked(ctx context.Context, v, parent Vertex, j *Job, ca
 			origDigest:   origVtx.Digest(),
 		}
 		jl.actives[dgst] = st
+		jl.opts.Metrics.SetActiveVertices(len(jl.actives))
 
 		if debugScheduler {
 			lg := bklog.G(ctx).
@@ -583,28 +876,42 @@ func (jl *Solver) loadUnlocked(ctx context.Context, v, parent Vertex, j *Job, ca
 }
 
 func (jl *Solver) connectProgressFromState(target, src *state) {
-	for j := range src.jobs {
-		j.mu.Lock()
-		pw := j.pw
-		span := j.span
-		j.mu.Unlock()
-		target.allPwMu.Lock()
-		if _, ok := target.allPw[pw]; !ok {
-			target.mpw.Add(pw)
-			target.allPw[pw] = struct{}{}
-			pw.Write(identity.NewID(), target.clientVertex)
-			if span != nil && span.SpanContext().IsValid() {
-				target.mspan.Add(span)
+	_ = walkStates(context.Background(), []*state{src}, walkStatesConcurrency, func(cur *state) []*state {
+		var parents []*state
+		for p := range cur.parents {
+			if pst, ok := jl.actives[p]; ok {
+				parents = append(parents, pst)
 			}
 		}
-		target.allPwMu.Unlock()
-	}
-	for p := range src.parents {
-		jl.connectProgressFromState(target, jl.actives[p])
-	}
+		return parents
+	}, func(cur *state) error {
+		for j := range cur.jobs {
+			j.mu.Lock()
+			pw := j.pw
+			span := j.span
+			j.mu.Unlock()
+			target.allPwMu.Lock()
+			if _, ok := target.allPw[pw]; !ok {
+				target.mpw.Add(pw)
+				target.allPw[pw] = struct{}{}
+				pw.Write(identity.NewID(), target.clientVertex)
+				if span != nil && span.SpanContext().IsValid() {
+					target.mspan.Add(span)
+				}
+			}
+			target.allPwMu.Unlock()
+		}
+		return nil
+	})
 }
 
 func (jl *Solver) NewJob(id string) (*Job, error) {
+	return jl.NewJobWithOptions(id, JobOptions{})
+}
+
+// NewJobWithOptions is like NewJob but additionally accepts scheduling
+// options such as Priority and Deadline.
+func (jl *Solver) NewJobWithOptions(id string, opts JobOptions) (*Job, error) {
 	jl.mu.Lock()
 	defer jl.mu.Unlock()
 
@@ -625,8 +932,19 @@ func (jl *Solver) NewJob(id string) (*Job, error) {
 		id:             id,
 		startedTime:    time.Now(),
 		uniqueID:       identity.NewID(),
+		Priority:       opts.Priority,
+		Deadline:       opts.Deadline,
+		resumed:        jl.resumable[id],
 	}
+	delete(jl.resumable, id)
 	jl.jobs[id] = j
+	jl.opts.Metrics.SetActiveJobs(len(jl.jobs))
+
+	if jl.opts.StateStore != nil {
+		if err := jl.opts.StateStore.Save(context.Background(), statestore.JobRecord{JobID: id, SessionID: j.SessionID}); err != nil {
+			bklog.G(context.Background()).WithError(err).WithField("job", id).Error("failed to checkpoint solver job state")
+		}
+	}
 
 	jl.updateCond.Broadcast()
 
@@ -687,6 +1005,8 @@ func (jl *Solver) deleteIfUnreferenced(k digest.Digest, st *state) {
 		}
 		st.Release()
 		delete(jl.actives, k)
+		jl.opts.Metrics.SetActiveVertices(len(jl.actives))
+		jl.maybeGCStateStore()
 	} else if debugScheduler {
 		var jobIDs []string
 		for j := range st.jobs {
@@ -701,6 +1021,27 @@ func (jl *Solver) deleteIfUnreferenced(k digest.Digest, st *state) {
 	}
 }
 
+// maybeGCStateStore runs opts.StateStore.GC in the background at most once
+// per stateStoreGCInterval, clearing records older than stateStoreGCTTL
+// that Job.Discard never got to delete (e.g. a crash). Called with jl.mu
+// held; the GC itself runs unlocked in a goroutine since it's a blocking
+// store operation.
+func (jl *Solver) maybeGCStateStore() {
+	if jl.opts.StateStore == nil {
+		return
+	}
+	if time.Since(jl.lastStateStoreGC) < stateStoreGCInterval {
+		return
+	}
+	jl.lastStateStoreGC = time.Now()
+	store := jl.opts.StateStore
+	go func() {
+		if err := store.GC(context.Background(), stateStoreGCTTL); err != nil {
+			bklog.G(context.Background()).WithError(err).Error("solver state store GC failed")
+		}
+	}()
+}
+
 func (j *Job) Build(ctx context.Context, e Edge) (CachedResultWithProvenance, error) {
 	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
 		j.mu.Lock()
@@ -708,6 +1049,12 @@ func (j *Job) Build(ctx context.Context, e Edge) (CachedResultWithProvenance, er
 		j.mu.Unlock()
 	}
 
+	if !j.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, j.Deadline)
+		defer cancel()
+	}
+
 	v, err := j.list.load(ctx, e.Vertex, nil, j)
 	if err != nil {
 		return nil, err
@@ -734,34 +1081,42 @@ func (wp *withProvenance) WalkProvenance(ctx context.Context, f func(ProvenanceP
 	}
 	wp.j.list.mu.RLock()
 	defer wp.j.list.mu.RUnlock()
-	m := map[digest.Digest]struct{}{}
-	return wp.j.walkProvenance(ctx, wp.e, f, m)
+	return wp.j.walkProvenance(ctx, wp.e, f)
 }
 
 // called with solver lock
-func (j *Job) walkProvenance(ctx context.Context, e Edge, f func(ProvenanceProvider) error, visited map[digest.Digest]struct{}) error {
-	if _, ok := visited[e.Vertex.Digest()]; ok {
-		return nil
-	}
-	visited[e.Vertex.Digest()] = struct{}{}
-	if st, ok := j.list.actives[e.Vertex.Digest()]; ok {
-		st.mu.Lock()
-		if st.op != nil && st.op.op != nil {
-			if wp, ok := st.op.op.(ProvenanceProvider); ok {
-				if err := f(wp); err != nil {
-					st.mu.Unlock()
-					return err
-				}
+//
+// Unlike addJobs and connectProgressFromState, this walks the Edge/Vertex
+// provenance graph via walkGraph directly rather than through the *state-
+// specialized walkStates: a provenance ancestor may no longer have an active
+// state (it could have already been released), but its Vertex.Inputs() must
+// still be followed to reach ancestors further back that do.
+//
+// f may now be invoked from multiple goroutines concurrently (once per
+// distinct Edge reached in parallel branches of the graph), same as any
+// other walkGraph visit; a caller-supplied f that accumulates into shared
+// state must synchronize itself.
+func (j *Job) walkProvenance(ctx context.Context, e Edge, f func(ProvenanceProvider) error) error {
+	return walkGraph(ctx, []Edge{e}, walkStatesConcurrency,
+		func(e Edge) digest.Digest { return e.Vertex.Digest() },
+		func(e Edge) []Edge { return e.Vertex.Inputs() },
+		func(e Edge) error {
+			st, ok := j.list.actives[e.Vertex.Digest()]
+			if !ok {
+				return nil
 			}
-		}
-		st.mu.Unlock()
-	}
-	for _, inp := range e.Vertex.Inputs() {
-		if err := j.walkProvenance(ctx, inp, f, visited); err != nil {
-			return err
-		}
-	}
-	return nil
+			st.mu.Lock()
+			defer st.mu.Unlock()
+			if st.op == nil || st.op.op == nil {
+				return nil
+			}
+			wp, ok := st.op.op.(ProvenanceProvider)
+			if !ok {
+				return nil
+			}
+			return f(wp)
+		},
+	)
 }
 
 func (j *Job) CloseProgress() {
@@ -775,6 +1130,12 @@ func (j *Job) Discard() error {
 
 	j.pw.Close()
 
+	if j.list.opts.StateStore != nil {
+		if err := j.list.opts.StateStore.Delete(context.Background(), j.id); err != nil {
+			bklog.G(context.TODO()).WithError(err).WithField("job", j.id).Error("failed to clear checkpointed solver job state")
+		}
+	}
+
 	for k, st := range j.list.actives {
 		st.mu.Lock()
 		if _, ok := st.jobs[j]; ok {
@@ -799,6 +1160,7 @@ func (j *Job) Discard() error {
 		j.list.mu.Lock()
 		defer j.list.mu.Unlock()
 		delete(j.list.jobs, j.id)
+		j.list.opts.Metrics.SetActiveJobs(len(j.list.jobs))
 	}()
 	return nil
 }
@@ -885,6 +1247,9 @@ type sharedOp struct {
 	cacheDone bool
 	cacheErr  error
 
+	execMu     sync.Mutex
+	execCancel context.CancelFunc
+
 	slowMu       sync.Mutex
 	slowCacheRes map[Index]digest.Digest
 	slowCacheErr map[Index]error
@@ -910,6 +1275,7 @@ func (c cacheWithCacheOpts) Records(ctx context.Context, ck *CacheKey) ([]*Cache
 }
 
 func (s *sharedOp) LoadCache(ctx context.Context, rec *CacheRecord) (Result, error) {
+	s.st.opts.Metrics.IncCacheHit()
 	ctx = progress.WithProgress(ctx, s.st.mpw)
 	if s.st.mspan.Span != nil {
 		ctx = trace.ContextWithSpan(ctx, s.st.mspan)
@@ -932,7 +1298,7 @@ func (s *sharedOp) CalcSlowCache(ctx context.Context, index Index, p PreprocessF
 		err = errdefs.WithOp(err, s.st.vtx.Sys(), s.st.vtx.Options().Description)
 		err = errdefs.WrapVertex(err, s.st.origDigest)
 	}()
-	flightControlKey := fmt.Sprintf("slow-compute-%d", index)
+	flightControlKey := fmt.Sprintf("slow-compute-%d-%s", index, slowCacheTransformerKey())
 	key, err := s.gDigest.Do(ctx, flightControlKey, func(ctx context.Context) (digest.Digest, error) {
 		s.slowMu.Lock()
 		// TODO: add helpers for these stored values
@@ -945,6 +1311,7 @@ func (s *sharedOp) CalcSlowCache(ctx context.Context, index Index, p PreprocessF
 			return "", err
 		}
 		s.slowMu.Unlock()
+		s.st.opts.Metrics.IncSlowCacheCompute()
 
 		complete := true
 		if p != nil {
@@ -972,6 +1339,9 @@ func (s *sharedOp) CalcSlowCache(ctx context.Context, index Index, p PreprocessF
 				ctx = trace.ContextWithSpan(ctx, s.st.mspan)
 			}
 			key, err = f(withAncestorCacheOpts(ctx, s.st), res, s.st)
+			if err == nil {
+				key, err = applySlowCacheTransformers(ctx, key, res, s.cacheOptsForIndex(index))
+			}
 		}
 		if err != nil {
 			select {
@@ -1109,15 +1479,55 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 		ctx = withAncestorCacheOpts(ctx, s.st)
 
 		// no cache hit. start evaluating the node
-		span, ctx := tracing.StartSpan(ctx, s.st.vtx.Name(), trace.WithAttributes(attribute.String("vertex", s.st.vtx.Digest().String())))
+		s.st.opts.Metrics.IncCacheExec()
+		jobIDs := s.st.attachedJobIDs()
+		span, ctx := tracing.StartSpan(ctx, s.st.vtx.Name(),
+			trace.WithAttributes(
+				attribute.String("vertex", s.st.vtx.Digest().String()),
+				attribute.StringSlice("buildkit.shared_with_jobs", jobIDs),
+			),
+			trace.WithLinks(s.st.jobSpanLinks()...),
+		)
 		s.st.execSpan = span
 		notifyCompleted := notifyStarted(ctx, &s.st.clientVertex, false)
+		execStarted := time.Now()
 		defer func() {
 			tracing.FinishWithError(span, retErr)
 			notifyCompleted(retErr, false)
+			s.st.opts.Metrics.ObserveVertexDuration(s.st.vtx.Name(), time.Since(execStarted))
+		}()
+
+		// Dispatch, if set, may hand this vertex to a remote worker instead
+		// of running it in this process. Since the decision is made inside
+		// this gExecRes.Do closure, flightcontrol continues to dedupe
+		// concurrent callers identically whether the vertex ends up running
+		// locally or remotely.
+		ctx, cancel := context.WithCancel(ctx)
+		s.execMu.Lock()
+		s.execCancel = cancel
+		s.execMu.Unlock()
+		defer func() {
+			s.execMu.Lock()
+			s.execCancel = nil
+			s.execMu.Unlock()
+			cancel()
 		}()
 
-		res, err := op.Exec(ctx, s.st, inputs)
+		var loc Location
+		if s.st.opts.Dispatcher != nil {
+			loc, err = s.st.opts.Dispatcher.Dispatch(ctx, s.st.vtx, inputs)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var res []Result
+		var remoteExporters []ExportableCacheKey
+		if loc.Remote != nil {
+			res, remoteExporters, err = loc.Remote.Exec(ctx, s.st.vtx, inputs)
+		} else {
+			res, err = op.Exec(ctx, s.st, inputs)
+		}
 		complete := true
 		if err != nil {
 			select {
@@ -1132,15 +1542,26 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 		}
 		if complete {
 			s.execDone = true
-			if res != nil {
+			if err == nil && res != nil {
 				var subExporters []ExportableCacheKey
 				s.subBuilder.mu.Lock()
 				if len(s.subBuilder.exporters) > 0 {
 					subExporters = append(subExporters, s.subBuilder.exporters...)
 				}
 				s.subBuilder.mu.Unlock()
+				if len(remoteExporters) > 0 {
+					subExporters = append(subExporters, remoteExporters...)
+				}
 
 				s.execRes = &execRes{execRes: wrapShared(res), execExporters: subExporters}
+			} else if err != nil && len(res) > 0 {
+				// op.Exec (or the remote executor) failed partway through a
+				// multi-output op but already produced some Results. Thread
+				// them through the returned error instead of dropping them,
+				// so releaseError reclaims the snapshots up the call chain.
+				// Nothing is stored in s.execRes for this path, so
+				// sharedOp.release() stays a no-op for it.
+				err = WrapPartialExec(err, res)
 			}
 			s.execErr = err
 		}
@@ -1155,6 +1576,17 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 	return unwrapShared(res.execRes), res.execExporters, nil
 }
 
+// cacheOptsForIndex returns the CacheOpts attached to the CacheMap result at
+// index, if CacheMap has already populated it, or nil otherwise.
+func (s *sharedOp) cacheOptsForIndex(index Index) CacheOpts {
+	s.slowMu.Lock()
+	defer s.slowMu.Unlock()
+	if int(index) < len(s.cacheRes) && s.cacheRes[index] != nil {
+		return s.cacheRes[index].Opts
+	}
+	return nil
+}
+
 func (s *sharedOp) getOp() (Op, error) {
 	s.opOnce.Do(func() {
 		s.subBuilder = s.st.builder()
@@ -1167,6 +1599,11 @@ func (s *sharedOp) getOp() (Op, error) {
 }
 
 func (s *sharedOp) release() {
+	s.execMu.Lock()
+	if s.execCancel != nil {
+		s.execCancel()
+	}
+	s.execMu.Unlock()
 	if s.execRes != nil {
 		for _, r := range s.execRes.execRes {
 			go r.Release(context.TODO())
@@ -1264,6 +1701,99 @@ func WrapSlowCache(err error, index Index, res Result) error {
 	return &SlowCacheError{Index: index, Result: res, error: err}
 }
 
+// PartialExecError wraps an error returned by Op.Exec alongside the partial
+// Results it had already produced before failing, so releaseError can
+// reclaim the snapshots instead of leaking them when a multi-output op
+// fails mid-way through.
+type PartialExecError struct {
+	error
+	Results []Result
+}
+
+func (e *PartialExecError) Unwrap() error {
+	return e.error
+}
+
+// Release releases every partial Result carried by this error. releaseError
+// finds it via this Release() error signature as it walks the error chain.
+func (e *PartialExecError) Release() error {
+	for _, r := range e.Results {
+		if r != nil {
+			r.Release(context.TODO())
+		}
+	}
+	return nil
+}
+
+// WrapPartialExec wraps err, if non-nil, with the partial Results Exec had
+// already produced before failing, mirroring SlowCacheError's Result field
+// for the slow-cache path.
+func WrapPartialExec(err error, results []Result) error {
+	if err == nil {
+		return nil
+	}
+	return &PartialExecError{error: err, Results: results}
+}
+
+// SlowCacheTransformer refines the digest a slow-cache ResultBasedCacheFunc
+// produced, e.g. to hash only file modes or to strip ownership, layering
+// semantic-aware invalidation on top of the default contenthash computation
+// without changing the Op interface.
+type SlowCacheTransformer func(ctx context.Context, key digest.Digest, res Result, opts CacheOpts) (digest.Digest, error)
+
+var (
+	slowCacheTransformersMu sync.Mutex
+	slowCacheTransformers   = map[string]SlowCacheTransformer{}
+)
+
+// RegisterSlowCacheTransformer registers a named SlowCacheTransformer. Every
+// registered transformer runs on every slow-cache computation; the set of
+// registered names is folded into the flightcontrol key so two builds with
+// different transformer sets never share a cache record, while builds with
+// an identical set still hit.
+func RegisterSlowCacheTransformer(name string, fn SlowCacheTransformer) {
+	slowCacheTransformersMu.Lock()
+	defer slowCacheTransformersMu.Unlock()
+	slowCacheTransformers[name] = fn
+}
+
+func slowCacheTransformerKey() string {
+	slowCacheTransformersMu.Lock()
+	defer slowCacheTransformersMu.Unlock()
+	if len(slowCacheTransformers) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(slowCacheTransformers))
+	for name := range slowCacheTransformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func applySlowCacheTransformers(ctx context.Context, key digest.Digest, res Result, opts CacheOpts) (digest.Digest, error) {
+	slowCacheTransformersMu.Lock()
+	names := make([]string, 0, len(slowCacheTransformers))
+	for name := range slowCacheTransformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fns := make([]SlowCacheTransformer, len(names))
+	for i, name := range names {
+		fns[i] = slowCacheTransformers[name]
+	}
+	slowCacheTransformersMu.Unlock()
+
+	for i, fn := range fns {
+		transformed, err := fn(ctx, key, res, opts)
+		if err != nil {
+			return "", errors.Wrapf(err, "slow cache transformer %q", names[i])
+		}
+		key = transformed
+	}
+	return key, nil
+}
+
 func releaseError(err error) {
 	if err == nil {
 		return
@@ -0,0 +1,112 @@
+// Package metrics defines the hooks the solver uses to report internal
+// behavior (active vertex/job counts, edge merges, cache hit/exec ratios,
+// slow-cache computations, flightcontrol dedup, and per-vertex execution
+// durations) without depending on a particular metrics backend.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Sink receives solver-internal events. All methods must be safe for
+// concurrent use, since the solver calls them from multiple goroutines.
+type Sink interface {
+	// SetActiveVertices reports the current size of Solver.actives.
+	SetActiveVertices(n int)
+	// SetActiveJobs reports the current number of jobs tracked by the Solver.
+	SetActiveJobs(n int)
+	// IncEdgeMerge counts a state.setEdge call that merged in a non-nil
+	// targetState.
+	IncEdgeMerge()
+	// IncCacheHit/IncCacheExec count whether a sharedOp vertex was satisfied
+	// from cache (LoadCache) or required running Exec.
+	IncCacheHit()
+	IncCacheExec()
+	// IncSlowCacheCompute counts a CalcSlowCache invocation that actually ran
+	// the preprocess/compute functions, as opposed to returning a memoized
+	// result.
+	IncSlowCacheCompute()
+	// IncFlightControlDedup counts a flightcontrol.Group.Do call for the
+	// given key that was coalesced into an already in-flight call, rather
+	// than starting a new one.
+	IncFlightControlDedup(key string)
+	// ObserveVertexDuration records the wall-clock duration between a
+	// vertex's notifyStarted and notifyCompleted.
+	ObserveVertexDuration(vertexName string, d time.Duration)
+}
+
+// Noop is a Sink that discards everything. It's the default used when
+// SolverOpt.Metrics is unset, so callers never need a nil check.
+type Noop struct{}
+
+func (Noop) SetActiveVertices(int)                       {}
+func (Noop) SetActiveJobs(int)                           {}
+func (Noop) IncEdgeMerge()                               {}
+func (Noop) IncCacheHit()                                {}
+func (Noop) IncCacheExec()                               {}
+func (Noop) IncSlowCacheCompute()                        {}
+func (Noop) IncFlightControlDedup(string)                {}
+func (Noop) ObserveVertexDuration(string, time.Duration) {}
+
+// otelSink is the default non-noop Sink, reporting through an OTel meter.
+type otelSink struct {
+	activeVertices metric.Int64UpDownCounter
+	activeJobs     metric.Int64UpDownCounter
+	edgeMerges     metric.Int64Counter
+	cacheHits      metric.Int64Counter
+	cacheExecs     metric.Int64Counter
+	slowCache      metric.Int64Counter
+	flightDedup    metric.Int64Counter
+	vertexDuration metric.Float64Histogram
+}
+
+// NewOTelSink builds a Sink that reports solver-internal counters and
+// histograms through meter, using the "buildkit.solver." instrument prefix.
+func NewOTelSink(meter metric.Meter) (Sink, error) {
+	s := &otelSink{}
+	var err error
+	if s.activeVertices, err = meter.Int64UpDownCounter("buildkit.solver.active_vertices"); err != nil {
+		return nil, err
+	}
+	if s.activeJobs, err = meter.Int64UpDownCounter("buildkit.solver.active_jobs"); err != nil {
+		return nil, err
+	}
+	if s.edgeMerges, err = meter.Int64Counter("buildkit.solver.edge_merges"); err != nil {
+		return nil, err
+	}
+	if s.cacheHits, err = meter.Int64Counter("buildkit.solver.cache_hits"); err != nil {
+		return nil, err
+	}
+	if s.cacheExecs, err = meter.Int64Counter("buildkit.solver.cache_execs"); err != nil {
+		return nil, err
+	}
+	if s.slowCache, err = meter.Int64Counter("buildkit.solver.slow_cache_computes"); err != nil {
+		return nil, err
+	}
+	if s.flightDedup, err = meter.Int64Counter("buildkit.solver.flightcontrol_dedup"); err != nil {
+		return nil, err
+	}
+	if s.vertexDuration, err = meter.Float64Histogram("buildkit.solver.vertex_duration_seconds"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *otelSink) SetActiveVertices(n int) { s.activeVertices.Add(context.Background(), int64(n)) }
+func (s *otelSink) SetActiveJobs(n int)     { s.activeJobs.Add(context.Background(), int64(n)) }
+func (s *otelSink) IncEdgeMerge()           { s.edgeMerges.Add(context.Background(), 1) }
+func (s *otelSink) IncCacheHit()            { s.cacheHits.Add(context.Background(), 1) }
+func (s *otelSink) IncCacheExec()           { s.cacheExecs.Add(context.Background(), 1) }
+func (s *otelSink) IncSlowCacheCompute()    { s.slowCache.Add(context.Background(), 1) }
+
+func (s *otelSink) IncFlightControlDedup(key string) {
+	s.flightDedup.Add(context.Background(), 1, metric.WithAttributes(attribute.String("key", key)))
+}
+
+func (s *otelSink) ObserveVertexDuration(vertexName string, d time.Duration) {
+	s.vertexDuration.Record(context.Background(), d.Seconds(), metric.WithAttributes(attribute.String("vertex", vertexName)))
+}